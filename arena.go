@@ -0,0 +1,416 @@
+package trie
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// nodeArena holds the nodes of an arena-backed trie (see
+// NewArenaTrie) in a single growing slice, rather than allocating
+// them one at a time. Children are found by index (each node holds
+// the index of its first child and of its next sibling) instead of
+// by pointer, and edges and data are ranges of a single byte slice,
+// so the nodes hold no pointers at all. Inserting therefore makes
+// no allocations (beyond the occasional growth of the slices) and
+// the garbage collector has nothing in the arena to scan.
+//
+// The costs are that siblings are scanned in turn (although by
+// their first runes, which are held in the nodes), and that nodes
+// removed by Delete (along with replaced edges and data) are not
+// reclaimed until the trie itself is.
+type nodeArena struct {
+	nodes []arenaNode
+	bytes []byte
+}
+
+// arenaNode is a node in the arena. Index 0 is a sentinel whose
+// children are the root rune nodes, which means that 0 can also
+// stand for "no node" in child and next.
+type arenaNode struct {
+	edge    uint32 // offset of the edge in bytes
+	edgeLen uint32
+	data    uint32 // offset of the data in bytes
+	dataLen uint32
+	weight  int
+	first   rune
+	child   uint32
+	next    uint32
+	entry   bool
+}
+
+// NewArenaTrie is used to create a new radix trie whose nodes are
+// kept in an arena, without pointers, rather than as Nodes. It has
+// the same structure and rules as a trie created with NewTrie, but
+// inserting makes far fewer allocations and the garbage collector
+// need not scan the nodes, which suits large tries that are mostly
+// added to (such as dictionaries). The trie cannot be folded (a
+// case-insensitive encoding decodes into ordinary Nodes instead).
+//
+// The Nodes passed by Find, Walk and the like are detached copies,
+// so they hold the value, data and weight of an entry but have no
+// children. The features which work on the nodes themselves (such
+// as encoding, Freeze, Validate, NewMatcher and Speller) first copy
+// the trie into ordinary Nodes.
+func NewArenaTrie() Trie {
+	return Trie{arena: newNodeArena()}
+}
+
+func newNodeArena() *nodeArena {
+	return &nodeArena{nodes: make([]arenaNode, 1)}
+}
+
+func (a *nodeArena) edge(i uint32) []byte {
+
+	n := &a.nodes[i]
+	return a.bytes[n.edge : n.edge+n.edgeLen]
+}
+
+// add appends a node for the edge s as the last child of parent
+// (whose last child so far is last, if any).
+func (a *nodeArena) add(parent uint32, last uint32, s string, entry bool) uint32 {
+
+	r, _ := utf8.DecodeRuneInString(s)
+	a.nodes = append(a.nodes, arenaNode{edge: uint32(len(a.bytes)), edgeLen: uint32(len(s)), first: r, entry: entry})
+	a.bytes = append(a.bytes, s...)
+	i := uint32(len(a.nodes) - 1)
+	if last == 0 {
+		a.nodes[parent].child = i
+	} else {
+		a.nodes[last].next = i
+	}
+	return i
+}
+
+// child returns the child of n whose edge begins with r (or 0),
+// along with the sibling before it (or the last child, if there
+// is no such child).
+func (a *nodeArena) child(n uint32, r rune) (uint32, uint32) {
+
+	prev := uint32(0)
+	for c := a.nodes[n].child; c != 0; c = a.nodes[c].next {
+		if a.nodes[c].first == r {
+			return c, prev
+		}
+		prev = c
+	}
+	return 0, prev
+}
+
+// insert adds the entry s, which has already been checked as for
+// Trie.Insert, returning false if it was already an entry.
+func (a *nodeArena) insert(s string) bool {
+
+	r, size := utf8.DecodeRuneInString(s)
+	n, last := a.child(0, r)
+	if n == 0 {
+		n = a.add(0, last, s[:size], false)
+	}
+
+	rest := s[size:]
+	for {
+		r, _ := utf8.DecodeRuneInString(rest)
+		c, last := a.child(n, r)
+		if c == 0 {
+			// No match in the children so attach to this node
+			a.add(n, last, rest, true)
+			return true
+		}
+
+		edge := a.edge(c)
+		m := commonRunePrefix(edge, rest)
+		if m < len(edge) {
+			a.split(c, m)
+		}
+		if m == len(rest) {
+			// Check for duplicate entries
+			if a.nodes[c].entry {
+				return false
+			}
+			a.nodes[c].entry = true
+			return true
+		}
+		n, rest = c, rest[m:]
+	}
+}
+
+// split divides node i at byte offset m of its edge, moving the
+// tail of the edge (which stays where it is in bytes), along with
+// the children and any entry, into a new and only child.
+func (a *nodeArena) split(i uint32, m int) {
+
+	n := a.nodes[i]
+	tail := n
+	tail.edge += uint32(m)
+	tail.edgeLen -= uint32(m)
+	tail.first, _ = utf8.DecodeRune(a.edge(i)[m:])
+	tail.next = 0
+	a.nodes = append(a.nodes, tail)
+	a.nodes[i] = arenaNode{edge: n.edge, edgeLen: uint32(m), first: n.first, child: uint32(len(a.nodes) - 1), next: n.next}
+}
+
+// merge is the reverse of split: node i absorbs its only child,
+// appending the child's edge and taking on its children and entry.
+func (a *nodeArena) merge(i uint32) {
+
+	n, c := a.nodes[i], a.nodes[a.nodes[i].child]
+	if n.edge+n.edgeLen != c.edge {
+		// The edges are not already side by side
+		edge := uint32(len(a.bytes))
+		a.bytes = append(a.bytes, a.edge(i)...)
+		a.bytes = append(a.bytes, a.edge(n.child)...)
+		n.edge = edge
+	}
+	n.edgeLen += c.edgeLen
+	n.data, n.dataLen, n.weight = c.data, c.dataLen, c.weight
+	n.child, n.entry = c.child, c.entry
+	a.nodes[i] = n
+}
+
+// delete removes the entry s from beneath n, and then tidies up
+// n's children, as for Trie.deleteRuneNode.
+func (a *nodeArena) delete(n uint32, s string) bool {
+
+	r, _ := utf8.DecodeRuneInString(s)
+	c, prev := a.child(n, r)
+	if c == 0 || !hasBytePrefix(s, a.edge(c)) {
+		return false
+	}
+	if len(s) == int(a.nodes[c].edgeLen) {
+		if !a.nodes[c].entry {
+			return false
+		}
+		a.nodes[c].entry = false
+		a.nodes[c].dataLen = 0
+		a.nodes[c].weight = 0
+	} else if !a.delete(c, s[a.nodes[c].edgeLen:]) {
+		return false
+	}
+
+	switch first := a.nodes[c].child; {
+	case a.nodes[c].entry:
+	case first == 0:
+		// Unlink the child (which may be a root)
+		if prev == 0 {
+			a.nodes[n].child = a.nodes[c].next
+		} else {
+			a.nodes[prev].next = a.nodes[c].next
+		}
+	case a.nodes[first].next == 0 && n != 0:
+		// Roots always hold just one rune
+		a.merge(c)
+	}
+	return true
+}
+
+// find returns the node whose path is s (which may or may not be
+// an entry), or 0.
+func (a *nodeArena) find(s string) uint32 {
+
+	n := uint32(0)
+	for s != "" {
+		r, _ := utf8.DecodeRuneInString(s)
+		c, _ := a.child(n, r)
+		if c == 0 || !hasBytePrefix(s, a.edge(c)) {
+			return 0
+		}
+		n, s = c, s[a.nodes[c].edgeLen:]
+	}
+	return n
+}
+
+// prefix returns the highest node whose path (which is also
+// returned) begins with s, as for prefixNode.
+func (a *nodeArena) prefix(s string) (uint32, string, bool) {
+
+	n, path := uint32(0), ""
+	for s != "" {
+		r, _ := utf8.DecodeRuneInString(s)
+		c, _ := a.child(n, r)
+		if c == 0 {
+			return 0, "", false
+		}
+		edge := a.edge(c)
+		switch {
+		case hasBytePrefix(s, edge):
+			s = s[len(edge):]
+		case len(s) < len(edge) && string(edge[:len(s)]) == s:
+			s = ""
+		default:
+			return 0, "", false
+		}
+		n, path = c, path+string(edge)
+	}
+	return n, path, true
+}
+
+// walkPrefixes calls fn for every entry which is a prefix of s,
+// as for Trie.walkPrefixes.
+func (a *nodeArena) walkPrefixes(s string, fn func(end int, n *Node)) {
+
+	n, matched := uint32(0), 0
+	for matched < len(s) {
+		r, _ := utf8.DecodeRuneInString(s[matched:])
+		c, _ := a.child(n, r)
+		if c == 0 || !hasBytePrefix(s[matched:], a.edge(c)) {
+			return
+		}
+		n, matched = c, matched+int(a.nodes[c].edgeLen)
+		if a.nodes[n].entry {
+			fn(matched, a.view(n))
+		}
+	}
+}
+
+// walk calls fn for every entry at or beneath n, as for Trie.Walk.
+func (a *nodeArena) walk(n uint32, prefix string, fn func(string, *Node) bool) bool {
+
+	if a.nodes[n].entry && !fn(prefix, a.view(n)) {
+		return false
+	}
+	for c := a.nodes[n].child; c != 0; c = a.nodes[c].next {
+		if !a.walk(c, prefix+string(a.edge(c)), fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// setData replaces the data of node i (which is left where it was
+// in bytes, if it is the same).
+func (a *nodeArena) setData(i uint32, data string) {
+
+	n := &a.nodes[i]
+	if string(a.bytes[n.data:n.data+n.dataLen]) == data {
+		return
+	}
+	n.data, n.dataLen = uint32(len(a.bytes)), uint32(len(data))
+	a.bytes = append(a.bytes, data...)
+}
+
+// view returns a detached copy of node i, without its children.
+func (a *nodeArena) view(i uint32) *Node {
+
+	n := &a.nodes[i]
+	v := makeNode(string(a.edge(i)), n.entry)
+	v.data = string(a.bytes[n.data : n.data+n.dataLen])
+	v.weight = n.weight
+	for c := n.child; c != 0; c = a.nodes[c].next {
+		v.childCount++
+	}
+	return &v
+}
+
+// node returns a copy of node i, and all of its descendants, made
+// of ordinary Nodes.
+func (a *nodeArena) node(i uint32) *Node {
+
+	n := a.view(i)
+	for c := a.nodes[i].child; c != 0; c = a.nodes[c].next {
+		n.children = append(n.children, a.node(c))
+	}
+	n.index = indexChildren(n.children)
+	return n
+}
+
+// copyNode appends a copy of n, and all of its descendants, as the
+// last child of parent (whose last child so far is last, if any).
+func (a *nodeArena) copyNode(parent uint32, last uint32, n *Node) uint32 {
+
+	i := a.add(parent, last, n.value, n.entry)
+	a.setData(i, n.data)
+	a.nodes[i].weight = n.weight
+	prev := uint32(0)
+	for _, c := range n.children {
+		prev = a.copyNode(i, prev, c)
+	}
+	return i
+}
+
+// tree returns the trie itself or, for an arena-backed trie, a copy
+// of it made of ordinary Nodes, for those features which work on
+// the nodes directly.
+func (t *Trie) tree() *Trie {
+
+	if t.arena == nil {
+		return t
+	}
+	tree := Trie{count: t.count}
+	for c := t.arena.nodes[0].child; c != 0; c = t.arena.nodes[c].next {
+		tree.child = append(tree.child, t.arena.node(c))
+	}
+	tree.roots = indexChildren(tree.child)
+	if t.reversed != nil {
+		tree.reversed = t.reversed.tree()
+	}
+	return &tree
+}
+
+// replace sets the contents of the trie to those of decoded, which
+// is copied into a new arena if the trie is arena-backed (unless it
+// is folded, as only Nodes can hold the original terms).
+func (t *Trie) replace(decoded Trie) {
+
+	if t.arena != nil && !decoded.fold {
+		a := newNodeArena()
+		last := uint32(0)
+		for _, c := range decoded.child {
+			last = a.copyNode(0, last, c)
+		}
+		decoded = Trie{count: decoded.count, arena: a}
+	}
+	*t = decoded
+}
+
+// check reports whether the nodes and edges of the arena agree
+// with each other, and that no node is reachable by more than one
+// path (which would otherwise make copying it loop).
+func (a *nodeArena) check() error {
+
+	seen := make([]bool, len(a.nodes))
+	var visit func(n uint32) error
+	visit = func(n uint32) error {
+		for c := a.nodes[n].child; c != 0; c = a.nodes[c].next {
+			if int(c) >= len(a.nodes) {
+				return fmt.Errorf("trie: arena node %d is out of range", c)
+			}
+			if seen[c] {
+				return fmt.Errorf("trie: arena node %d is reachable by more than one path", c)
+			}
+			seen[c] = true
+			x := &a.nodes[c]
+			if uint64(x.edge)+uint64(x.edgeLen) > uint64(len(a.bytes)) || uint64(x.data)+uint64(x.dataLen) > uint64(len(a.bytes)) {
+				return fmt.Errorf("trie: arena node %d lies outside the arena", c)
+			}
+			if r, _ := utf8.DecodeRune(a.edge(c)); r != x.first {
+				return fmt.Errorf("trie: arena node %d has first rune %q but an edge beginning with %q", c, x.first, r)
+			}
+			if err := visit(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return visit(0)
+}
+
+// hasBytePrefix reports whether s begins with the bytes b (which,
+// unlike strings.HasPrefix, does not need b to be copied).
+func hasBytePrefix(s string, b []byte) bool {
+	return len(s) >= len(b) && s[:len(b)] == string(b)
+}
+
+// commonRunePrefix returns the length in bytes of the common prefix
+// of edge and s, which will always fall on a rune boundary.
+func commonRunePrefix(edge []byte, s string) int {
+
+	m := 0
+	for m < len(edge) && m < len(s) && edge[m] == s[m] {
+		m++
+	}
+	// As the bytes before m are shared, m is part way through a
+	// rune in both or in neither
+	for m < len(edge) && m > 0 && !utf8.RuneStart(edge[m]) {
+		m--
+	}
+	return m
+}
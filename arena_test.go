@@ -0,0 +1,193 @@
+package trie
+
+import (
+	"bytes"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArenaTrie(t *testing.T) {
+
+	plain, arena := NewTrie(), NewArenaTrie()
+	for _, trie := range []*Trie{&plain, &arena} {
+		for _, s := range benchmarkKeys {
+			trie.Insert(s)
+		}
+		trie.Insert("大豆")
+		trie.Insert("大米")
+		trie.InsertData("rubicon", "river")
+		trie.InsertWeight("slowly", 9)
+		trie.Delete("romanus")
+	}
+	checkValid(t, "arena", &arena)
+
+	if arena.Count() != plain.Count() {
+		t.Errorf("expected count to be %d, but was %d", plain.Count(), arena.Count())
+	}
+	if !reflect.DeepEqual(arena.Entries(), plain.Entries()) {
+		t.Errorf("expected entries %v, but was %v", plain.Entries(), arena.Entries())
+	}
+	if _, n := arena.Find("rubicon"); n == nil || n.Data() != "river" {
+		t.Errorf("expected data for 'rubicon'")
+	}
+	if _, n := arena.Find("slowly"); n == nil || n.Weight() != 9 {
+		t.Errorf("expected a weight for 'slowly'")
+	}
+
+	// Including prefixes which end part way along an edge (or a rune)
+	for _, prefix := range []string{"", "rub", "romu", "大", "\xe5", "大\xe8", "rx"} {
+		if entries, expected := arena.WithPrefix(prefix), plain.WithPrefix(prefix); !reflect.DeepEqual(entries, expected) {
+			t.Errorf("prefix '%s': expected entries %v, but was %v", prefix, expected, entries)
+		}
+	}
+	if longest, found := arena.LongestPrefix("slowlyest"); !found || longest != "slowly" {
+		t.Errorf("expected longest prefix 'slowly', but was '%s'", longest)
+	}
+
+	// The features which work on Nodes see the same structure
+	var plainTree, arenaTree strings.Builder
+	plain.Render(&plainTree)
+	arena.Render(&arenaTree)
+	if arenaTree.String() != plainTree.String() {
+		t.Errorf("expected the tree\n%s\nbut was\n%s", plainTree.String(), arenaTree.String())
+	}
+	plainBinary, _ := plain.MarshalBinary()
+	arenaBinary, _ := arena.MarshalBinary()
+	if !bytes.Equal(arenaBinary, plainBinary) {
+		t.Errorf("expected the same binary encoding")
+	}
+
+	// Decoding into an arena-backed trie keeps it in the arena
+	decoded := NewArenaTrie()
+	if err := decoded.UnmarshalBinary(plainBinary); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	checkValid(t, "decoded", &decoded)
+	if decoded.arena == nil || !reflect.DeepEqual(decoded.Entries(), plain.Entries()) {
+		t.Errorf("expected the entries to be decoded into the arena")
+	}
+	if _, n := decoded.Find("rubicon"); n == nil || n.Data() != "river" {
+		t.Errorf("expected decoded data for 'rubicon'")
+	}
+
+	// Deleting (which merges edges) leaves the same structure
+	for i, s := range plain.Entries() {
+		if i%2 == 0 {
+			plain.Delete(s)
+			arena.Delete(s)
+		}
+	}
+	checkValid(t, "arena after deleting half", &arena)
+	plainTree.Reset()
+	arenaTree.Reset()
+	plain.Render(&plainTree)
+	arena.Render(&arenaTree)
+	if arenaTree.String() != plainTree.String() {
+		t.Errorf("expected the tree\n%s\nbut was\n%s", plainTree.String(), arenaTree.String())
+	}
+
+	// Deleting everything leaves an empty (but still usable) trie
+	for _, s := range arena.Entries() {
+		if !arena.Delete(s) {
+			t.Errorf("expected to delete '%s'", s)
+		}
+	}
+	checkValid(t, "arena after delete", &arena)
+	if !arena.isEmpty() || !arena.Insert("slowest") {
+		t.Errorf("expected to insert into the emptied trie")
+	}
+}
+
+func TestArenaTrieAllocs(t *testing.T) {
+
+	insert := func(newTrie func() Trie) float64 {
+		return testing.AllocsPerRun(10, func() {
+			trie := newTrie()
+			for _, s := range benchmarkKeys {
+				trie.Insert(s)
+			}
+		})
+	}
+	nodes, arena := insert(NewTrie), insert(NewArenaTrie)
+	if arena*2 > nodes {
+		t.Errorf("expected the arena to make at most half the allocations, but made %.0f (to %.0f)", arena, nodes)
+	}
+}
+
+func TestArenaNodeHasNoPointers(t *testing.T) {
+
+	// Otherwise the garbage collector would have to scan the arena
+	nodes := reflect.TypeOf(arenaNode{})
+	for i := 0; i < nodes.NumField(); i++ {
+		switch f := nodes.Field(i); f.Type.Kind() {
+		case reflect.Bool, reflect.Int, reflect.Int32, reflect.Uint32:
+		default:
+			t.Errorf("expected arena nodes to hold no pointers, but %s is a %s", f.Name, f.Type)
+		}
+	}
+}
+
+func BenchmarkInsertNodes(b *testing.B) {
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		trie := NewTrie()
+		for _, s := range benchmarkKeys {
+			trie.Insert(s)
+		}
+	}
+}
+
+func BenchmarkInsertArena(b *testing.B) {
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		trie := NewArenaTrie()
+		for _, s := range benchmarkKeys {
+			trie.Insert(s)
+		}
+	}
+}
+
+// benchmarkGC reports how long a garbage collection takes while the
+// structure made by build is live.
+func benchmarkGC(b *testing.B, build func() any) {
+
+	live := build()
+	runtime.GC()
+	b.ResetTimer()
+
+	var total time.Duration
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		runtime.GC()
+		total += time.Since(start)
+	}
+	b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), "gc-ns/op")
+	runtime.KeepAlive(live)
+}
+
+func BenchmarkGCNodes(b *testing.B) {
+
+	benchmarkGC(b, func() any {
+		trie := NewTrie()
+		for _, s := range wideKeys() {
+			trie.Insert(s)
+		}
+		return &trie
+	})
+}
+
+func BenchmarkGCArena(b *testing.B) {
+
+	benchmarkGC(b, func() any {
+		trie := NewArenaTrie()
+		for _, s := range wideKeys() {
+			trie.Insert(s)
+		}
+		return &trie
+	})
+}
@@ -0,0 +1,87 @@
+package trie
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrInvalidKey is returned when a key could not be inserted
+	// (for instance because it has fewer than two runes).
+	ErrInvalidKey = errors.New("trie: invalid key")
+
+	// ErrDuplicateKey is returned when a key occurs more than once.
+	ErrDuplicateKey = errors.New("trie: duplicate key")
+
+	// ErrUnsorted is returned when keys which were expected to be
+	// in lexicographic order were not.
+	ErrUnsorted = errors.New("trie: keys are not sorted")
+)
+
+// BuildFromSorted constructs a trie from keys, which must be in
+// strictly increasing lexicographic (byte) order once leading and
+// trailing whitespace has been removed - as for Insert. The trie
+// is built in a single pass, without the searching and splitting
+// needed by repeated calls to Insert, which makes it much faster
+// for loading large dictionaries. Keys are validated as for Insert
+// and an error is returned for the first key which is invalid, a
+// duplicate or out of order.
+func BuildFromSorted(keys iter.Seq[string]) (*Trie, error) {
+
+	t := NewTrie()
+
+	// The path to the entry for the previous key, along with the
+	// offset in the key at which each node on it ends
+	var path []*Node
+	var ends []int
+	prev := ""
+
+	for key := range keys {
+		trimmed := strings.TrimSpace(key)
+		if len(trimmed) < 2 || utf8.RuneCountInString(trimmed) < 2 || !utf8.ValidString(trimmed) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+		if trimmed == prev {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateKey, key)
+		}
+		if trimmed < prev {
+			return nil, fmt.Errorf("%w: %q follows %q", ErrUnsorted, key, prev)
+		}
+
+		_, size := utf8.DecodeRuneInString(trimmed)
+		if len(path) == 0 || path[0].value != trimmed[:size] {
+			t.makeRuneNode(trimmed)
+			root := t.child[len(t.child)-1]
+			path = append(path[:0], root, root.children[0])
+			ends = append(ends[:0], size, len(trimmed))
+			prev = trimmed
+			continue
+		}
+
+		// Sorted order means that the new key can only diverge from
+		// the previous one somewhere along its path (it cannot be a
+		// prefix of it), so the new entry is always the last child
+		common := size + t.findRuneMatch(prev[size:], trimmed[size:])
+		i := 0
+		for i+1 < len(path) && ends[i+1] <= common {
+			i++
+		}
+		if ends[i] < common {
+			// Divergence is part way along the next node's edge
+			next := path[i+1]
+			next.split(common - ends[i])
+			i++
+			path[i], ends[i] = next, common
+		}
+
+		child := path[i].makeChildNode(trimmed[common:], true)
+		t.count++
+		path = append(path[:i+1], child)
+		ends = append(ends[:i+1], len(trimmed))
+		prev = trimmed
+	}
+	return &t, nil
+}
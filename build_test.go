@@ -0,0 +1,154 @@
+package trie
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestBuildFromSorted(t *testing.T) {
+
+	buildTests := []struct {
+		name string
+		keys []string
+	}{
+		{
+			name: "no keys",
+			keys: []string{},
+		},
+		{
+			name: "'r' keys",
+			keys: []string{"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus"},
+		},
+		{
+			name: "'s' keys with entries along the path",
+			keys: []string{"slow", "slower", "slowly"},
+		},
+		{
+			name: "keys with several roots",
+			keys: []string{"roman", "romane", "romanus", "slow", "slowly", "test", "toaster", "toasting"},
+		},
+		{
+			name: "keys diverging part way along earlier splits",
+			keys: []string{"abcdef", "abcdxy", "abxyz", "axe"},
+		},
+		{
+			name: "chinese keys",
+			keys: []string{"大蒜", "大豆", "大豆油", "小麦"},
+		},
+		{
+			name: "keys with surrounding whitespace",
+			keys: []string{" romane", "romanus\n"},
+		},
+	}
+
+	for _, test := range buildTests {
+		built, err := BuildFromSorted(slices.Values(test.keys))
+		if err != nil {
+			t.Errorf("test '%s': unexpected error: %v", test.name, err)
+			continue
+		}
+		checkValid(t, test.name, built)
+
+		// Sorted insertion produces the same structure
+		inserted := NewTrie()
+		for _, s := range test.keys {
+			inserted.Insert(s)
+		}
+		if built.String() != inserted.String() {
+			t.Errorf("test '%s': expected structure\n%s\nbut was\n%s", test.name, inserted.String(), built.String())
+		}
+		if built.Count() != inserted.Count() {
+			t.Errorf("test '%s': expected count to be %d, but was %d", test.name, inserted.Count(), built.Count())
+		}
+	}
+}
+
+func TestBuildFromSortedErrors(t *testing.T) {
+
+	errorTests := []struct {
+		name string
+		keys []string
+		err  error
+	}{
+		{
+			name: "key too short",
+			keys: []string{"romane", "s"},
+			err:  ErrInvalidKey,
+		},
+		{
+			name: "single ideogram",
+			keys: []string{"大"},
+			err:  ErrInvalidKey,
+		},
+		{
+			name: "duplicate key",
+			keys: []string{"romane", "romane"},
+			err:  ErrDuplicateKey,
+		},
+		{
+			name: "duplicate key once trimmed",
+			keys: []string{"romane", " romane "},
+			err:  ErrDuplicateKey,
+		},
+		{
+			name: "unsorted keys under one root",
+			keys: []string{"romanus", "romane"},
+			err:  ErrUnsorted,
+		},
+		{
+			name: "prefix after its extension",
+			keys: []string{"slower", "slow"},
+			err:  ErrUnsorted,
+		},
+		{
+			name: "unsorted roots",
+			keys: []string{"slow", "romane"},
+			err:  ErrUnsorted,
+		},
+	}
+
+	for _, test := range errorTests {
+		built, err := BuildFromSorted(slices.Values(test.keys))
+		if !errors.Is(err, test.err) {
+			t.Errorf("test '%s': expected error to be %v, but was %v", test.name, test.err, err)
+		}
+		if built != nil {
+			t.Errorf("test '%s': expected no trie to be returned", test.name)
+		}
+	}
+}
+
+var benchmarkKeys = func() []string {
+	var keys []string
+	for _, a := range "abcdefghij" {
+		for _, b := range "klmnopqrst" {
+			for _, c := range "aeiou" {
+				for _, suffix := range []string{"", "ing", "ation", "ed", "er"} {
+					keys = append(keys, string([]rune{a, b, c})+suffix)
+				}
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}()
+
+func BenchmarkBuildFromSorted(b *testing.B) {
+
+	for i := 0; i < b.N; i++ {
+		if _, err := BuildFromSorted(slices.Values(benchmarkKeys)); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func BenchmarkInsertSorted(b *testing.B) {
+
+	for i := 0; i < b.N; i++ {
+		trie := NewTrie()
+		for _, s := range benchmarkKeys {
+			trie.Insert(s)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package trie
+
+import (
+	"encoding/binary"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DAWG is a read-only, minimized directed acyclic word graph of the
+// entries of a trie, as made by Freeze. Unlike the trie, in which
+// every branch holds its own copy of common endings (such as "ing"
+// or "ation"), a DAWG shares every equivalent subtree: any two
+// states from which exactly the same endings lead to entries are
+// merged into one.
+//
+// To share as much as possible, the DAWG steps one rune at a time
+// (rather than along compressed edges), with the transitions of
+// each state held in sorted order. So entries are enumerated in
+// sorted order, rather than in the order in which their nodes were
+// created. Data and weights are not kept.
+type DAWG struct {
+	final   []bool
+	first   []int32 // the transitions of state i are first[i]:first[i+1]
+	labels  []rune
+	targets []int32
+	start   int32
+	count   int
+}
+
+// dawgBuilder hash-conses the states as they are made, bottom up,
+// so that each distinct state is only made once.
+type dawgBuilder struct {
+	d        *DAWG
+	registry map[string]int32
+	key      []byte
+}
+
+type dawgEdge struct {
+	label  rune
+	target int32
+}
+
+// Freeze converts the trie into a minimized DAWG. The trie itself
+// is unchanged, and later changes to it are not seen by the DAWG.
+// Case-insensitive tries cannot be frozen (see ErrFolded), as the
+// DAWG would hold only their folded terms.
+func (t *Trie) Freeze() (*DAWG, error) {
+
+	if t.fold {
+		return nil, ErrFolded
+	}
+	t = t.tree()
+
+	b := dawgBuilder{d: &DAWG{first: []int32{0}, count: t.count}, registry: map[string]int32{}}
+
+	var roots []dawgEdge
+	for _, c := range t.child {
+		r, _ := utf8.DecodeRuneInString(c.value)
+		roots = append(roots, dawgEdge{label: r, target: b.node(c)})
+	}
+	b.d.start = b.state(false, roots)
+	return b.d, nil
+}
+
+// node returns the state at the end of n, having made the states
+// for everything below it.
+func (b *dawgBuilder) node(n *Node) int32 {
+
+	var edges []dawgEdge
+	for _, c := range n.children {
+		runes := []rune(c.value)
+
+		// Chain back from the end of the child to just after its
+		// first rune
+		s := b.node(c)
+		for j := len(runes) - 1; j >= 1; j-- {
+			s = b.state(false, []dawgEdge{{label: runes[j], target: s}})
+		}
+		edges = append(edges, dawgEdge{label: runes[0], target: s})
+	}
+	return b.state(n.entry, edges)
+}
+
+// state returns the state with the given finality and transitions,
+// making it only if there is no such state already.
+func (b *dawgBuilder) state(final bool, edges []dawgEdge) int32 {
+
+	sort.Slice(edges, func(i, j int) bool { return edges[i].label < edges[j].label })
+
+	b.key = b.key[:0]
+	if final {
+		b.key = append(b.key, 1)
+	} else {
+		b.key = append(b.key, 0)
+	}
+	for _, e := range edges {
+		b.key = binary.AppendVarint(b.key, int64(e.label))
+		b.key = binary.AppendVarint(b.key, int64(e.target))
+	}
+	if s, ok := b.registry[string(b.key)]; ok {
+		return s
+	}
+
+	d := b.d
+	s := int32(len(d.final))
+	d.final = append(d.final, final)
+	for _, e := range edges {
+		d.labels = append(d.labels, e.label)
+		d.targets = append(d.targets, e.target)
+	}
+	d.first = append(d.first, int32(len(d.labels)))
+	b.registry[string(b.key)] = s
+	return s
+}
+
+// Count returns the number of entries in the DAWG.
+func (d *DAWG) Count() int {
+	return d.count
+}
+
+// States returns the number of states in the DAWG, which (as each
+// state stands for a rune) may be compared with the total number
+// of runes in the edges of the trie to see how much was shared.
+func (d *DAWG) States() int {
+	return len(d.final)
+}
+
+// next returns the state reached from s by r, or -1.
+func (d *DAWG) next(s int32, r rune) int32 {
+
+	lo, hi := int(d.first[s]), int(d.first[s+1])
+	i := lo + sort.Search(hi-lo, func(i int) bool { return d.labels[lo+i] >= r })
+	if i < hi && d.labels[i] == r {
+		return d.targets[i]
+	}
+	return -1
+}
+
+// walk follows s from the start, returning the state reached (or
+// -1 if s leads nowhere).
+func (d *DAWG) walk(s string) int32 {
+
+	state := d.start
+	for _, r := range s {
+		if state = d.next(state, r); state < 0 {
+			return -1
+		}
+	}
+	return state
+}
+
+// Find is used to search for a specific term in the DAWG.
+func (d *DAWG) Find(s string) bool {
+
+	// Remove leading & trailing whitespace
+	trimmed := strings.TrimSpace(s)
+
+	// Sanity check (should catch empty strings too)
+	if len(trimmed) < 2 {
+		return false
+	}
+
+	state := d.walk(trimmed)
+	return state >= 0 && d.final[state]
+}
+
+// Walk calls fn for every entry in the DAWG, in sorted order (by
+// rune). The walk stops early if fn returns false.
+func (d *DAWG) Walk(fn func(s string) bool) {
+	d.walkState(d.start, []byte{}, fn)
+}
+
+func (d *DAWG) walkState(s int32, prefix []byte, fn func(string) bool) bool {
+
+	if d.final[s] && !fn(string(prefix)) {
+		return false
+	}
+	for i := d.first[s]; i < d.first[s+1]; i++ {
+		if !d.walkState(d.targets[i], utf8.AppendRune(prefix, d.labels[i]), fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Entries returns every entry in the DAWG, in sorted order.
+func (d *DAWG) Entries() []string {
+
+	entries := make([]string, 0, d.count)
+	d.Walk(func(s string) bool {
+		entries = append(entries, s)
+		return true
+	})
+	return entries
+}
+
+// WithPrefix returns every entry which begins with prefix, in sorted
+// order. As with Trie, the prefix is not trimmed.
+func (d *DAWG) WithPrefix(prefix string) []string {
+
+	entries := []string{}
+	state := d.walk(prefix)
+	if state < 0 {
+		return entries
+	}
+	d.walkState(state, []byte(prefix), func(s string) bool {
+		entries = append(entries, s)
+		return true
+	})
+	return entries
+}
+
+// LongestPrefix returns the longest entry which is a prefix of s
+// (which may be s itself), if there is one.
+func (d *DAWG) LongestPrefix(s string) (string, bool) {
+
+	state, longest := d.start, 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if state = d.next(state, r); state < 0 {
+			break
+		}
+		i += size
+		if d.final[state] {
+			longest = i
+		}
+	}
+	return s[:longest], longest > 0
+}
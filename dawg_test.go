@@ -0,0 +1,155 @@
+package trie
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+
+	trie := NewTrie()
+	for _, s := range []string{"walking", "talking", "walked", "talked", "walk", "talk"} {
+		trie.Insert(s)
+	}
+	dawg, err := trie.Freeze()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// start, w|t, a, l, k (final), i, n, e, then one shared final
+	// state for the ends of "ing" and "ed"
+	if dawg.States() != 9 {
+		t.Errorf("expected 9 states, but was %d", dawg.States())
+	}
+	if dawg.Count() != 6 {
+		t.Errorf("expected count to be 6, but was %d", dawg.Count())
+	}
+
+	expected := []string{"talk", "talked", "talking", "walk", "walked", "walking"}
+	if entries := dawg.Entries(); !reflect.DeepEqual(entries, expected) {
+		t.Errorf("expected entries %v, but was %v", expected, entries)
+	}
+
+	findTests := []struct {
+		value string
+		found bool
+	}{
+		{value: "walking", found: true},
+		{value: " talk\n", found: true},
+		{value: "wal", found: false},
+		{value: "walkin", found: false},
+		{value: "stalking", found: false},
+		{value: "w", found: false},
+		{value: "", found: false},
+	}
+
+	for _, test := range findTests {
+		if found := dawg.Find(test.value); found != test.found {
+			t.Errorf("find '%s': expected found to be %t", test.value, test.found)
+		}
+	}
+
+	prefixTests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{prefix: "", expected: expected},
+		{prefix: "walke", expected: []string{"walked"}},
+		{prefix: "tal", expected: []string{"talk", "talked", "talking"}},
+		{prefix: "x", expected: []string{}},
+	}
+
+	for _, test := range prefixTests {
+		if entries := dawg.WithPrefix(test.prefix); !reflect.DeepEqual(entries, test.expected) {
+			t.Errorf("prefix '%s': expected %v, but was %v", test.prefix, test.expected, entries)
+		}
+	}
+
+	longestTests := []struct {
+		value    string
+		expected string
+		found    bool
+	}{
+		{value: "walkers", expected: "walk", found: true},
+		{value: "talkeddd", expected: "talked", found: true},
+		{value: "wa\xffk", expected: "", found: false},
+		{value: "stalk", expected: "", found: false},
+	}
+
+	for _, test := range longestTests {
+		if longest, found := dawg.LongestPrefix(test.value); longest != test.expected || found != test.found {
+			t.Errorf("longest '%s': expected ('%s', %t), but was ('%s', %t)", test.value, test.expected, test.found, longest, found)
+		}
+	}
+}
+
+func TestFreezeMatchesTrie(t *testing.T) {
+
+	trie := getTrie(7, 'r')
+	for _, s := range []string{"slow", "slower", "slowly", "大豆", "黄豆", "大米"} {
+		trie.Insert(s)
+	}
+	dawg, err := trie.Freeze()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := trie.Entries()
+	slices.Sort(entries)
+	if !reflect.DeepEqual(dawg.Entries(), entries) {
+		t.Errorf("expected entries %v, but was %v", entries, dawg.Entries())
+	}
+	for _, s := range entries {
+		if !dawg.Find(s) {
+			t.Errorf("expected to find '%s'", s)
+		}
+	}
+
+	// Changing the trie does not change the DAWG
+	trie.Delete("slow")
+	if !dawg.Find("slow") {
+		t.Errorf("expected the DAWG to be unaffected by changes to the trie")
+	}
+
+	empty := NewTrie()
+	if d, err := empty.Freeze(); err != nil || d.Count() != 0 || len(d.Entries()) != 0 || d.Find("ab") {
+		t.Errorf("expected an empty DAWG")
+	}
+}
+
+func TestFreezeFolded(t *testing.T) {
+
+	trie := NewFoldedTrie()
+	trie.Insert("iPhone")
+	if d, err := trie.Freeze(); err != ErrFolded || d != nil {
+		t.Errorf("expected error to be %v, but was %v", ErrFolded, err)
+	}
+}
+
+func TestFreezeShares(t *testing.T) {
+
+	trie := NewTrie()
+	for _, s := range benchmarkKeys {
+		trie.Insert(s)
+	}
+	dawg, err := trie.Freeze()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runes := 0
+	var count func(n *Node)
+	count = func(n *Node) {
+		runes += len([]rune(n.value))
+		for _, c := range n.children {
+			count(c)
+		}
+	}
+	for _, c := range trie.child {
+		count(c)
+	}
+	if dawg.States() >= runes/10 {
+		t.Errorf("expected far fewer states than the %d runes in the trie, but was %d", runes, dawg.States())
+	}
+}
@@ -0,0 +1,98 @@
+package trie
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// DOTOptions controls the graph written by WriteDOT.
+type DOTOptions struct {
+
+	// MaxDepth limits the depth of the nodes written, where the
+	// root rune nodes are at depth 1. Nodes whose children were
+	// cut off are drawn dashed. Zero means no limit.
+	MaxDepth int
+
+	// Prefix restricts the graph to the nodes leading to prefix
+	// and those beneath it. An empty prefix means the whole trie.
+	Prefix string
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteDOT writes the trie to w as a Graphviz DOT digraph. Every
+// node is drawn as a circle below an unlabelled root point, and
+// every edge is labelled with the (possibly compressed) value of
+// the node it leads to. Entry nodes are drawn as double circles
+// labelled with their term.
+func (t *Trie) WriteDOT(w io.Writer, opts DOTOptions) error {
+
+	t = t.tree()
+	bw := bufio.NewWriter(w)
+	d := dotWriter{w: bw, opts: opts}
+
+	fmt.Fprintln(bw, "digraph trie {")
+	fmt.Fprintln(bw, "\tnode [shape=circle, label=\"\"];")
+	fmt.Fprintln(bw, "\tn0 [shape=point];")
+
+	if opts.Prefix == "" {
+		for _, c := range t.child {
+			d.node(0, c, c.value, 1)
+		}
+	} else {
+		_, size := utf8.DecodeRuneInString(opts.Prefix)
+		for _, c := range t.child {
+			if c.value == opts.Prefix[:size] {
+				d.node(0, c, c.value, 1)
+				break
+			}
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+type dotWriter struct {
+	w    *bufio.Writer
+	opts DOTOptions
+	next int
+}
+
+func (d *dotWriter) node(parent int, n *Node, path string, depth int) {
+
+	d.next++
+	id := d.next
+
+	var attrs []string
+	if n.entry {
+		attrs = append(attrs, "shape=doublecircle", fmt.Sprintf("label=\"%s\"", dotEscaper.Replace(path)))
+	}
+	if d.opts.MaxDepth > 0 && depth >= d.opts.MaxDepth && len(n.children) > 0 {
+		attrs = append(attrs, "style=dashed")
+	}
+	if len(attrs) > 0 {
+		fmt.Fprintf(d.w, "\tn%d [%s];\n", id, strings.Join(attrs, ", "))
+	} else {
+		fmt.Fprintf(d.w, "\tn%d;\n", id)
+	}
+	fmt.Fprintf(d.w, "\tn%d -> n%d [label=\"%s\"];\n", parent, id, dotEscaper.Replace(n.value))
+
+	if d.opts.MaxDepth > 0 && depth >= d.opts.MaxDepth {
+		return
+	}
+	for _, c := range n.children {
+		if d.onPrefix(path + c.value) {
+			d.node(id, c, path+c.value, depth+1)
+		}
+	}
+}
+
+// onPrefix reports whether a node with the given path is on the
+// way to (or beneath) the prefix of interest.
+func (d *dotWriter) onPrefix(path string) bool {
+	return strings.HasPrefix(path, d.opts.Prefix) || strings.HasPrefix(d.opts.Prefix, path)
+}
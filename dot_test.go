@@ -0,0 +1,97 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+
+	dotTests := []struct {
+		name     string
+		trie     Trie
+		opts     DOTOptions
+		expected string
+	}{
+		{
+			name: "empty trie",
+			trie: getTrie(0, 's'),
+			opts: DOTOptions{},
+			expected: `digraph trie {
+	node [shape=circle, label=""];
+	n0 [shape=point];
+}
+`,
+		},
+		{
+			name: "'s' trie with three elements",
+			trie: getTrie(3, 's'),
+			opts: DOTOptions{},
+			expected: `digraph trie {
+	node [shape=circle, label=""];
+	n0 [shape=point];
+	n1;
+	n0 -> n1 [label="s"];
+	n2 [shape=doublecircle, label="slow"];
+	n1 -> n2 [label="low"];
+	n3 [shape=doublecircle, label="slower"];
+	n2 -> n3 [label="er"];
+	n4 [shape=doublecircle, label="slowly"];
+	n2 -> n4 [label="ly"];
+}
+`,
+		},
+		{
+			name: "'s' trie limited in depth",
+			trie: getTrie(3, 's'),
+			opts: DOTOptions{MaxDepth: 2},
+			expected: `digraph trie {
+	node [shape=circle, label=""];
+	n0 [shape=point];
+	n1;
+	n0 -> n1 [label="s"];
+	n2 [shape=doublecircle, label="slow", style=dashed];
+	n1 -> n2 [label="low"];
+}
+`,
+		},
+		{
+			name: "'t' trie restricted to a prefix",
+			trie: getTrie(3, 't'),
+			opts: DOTOptions{Prefix: "toasti"},
+			expected: `digraph trie {
+	node [shape=circle, label=""];
+	n0 [shape=point];
+	n1;
+	n0 -> n1 [label="t"];
+	n2;
+	n1 -> n2 [label="oast"];
+	n3 [shape=doublecircle, label="toasting"];
+	n2 -> n3 [label="ing"];
+}
+`,
+		},
+		{
+			name: "'t' trie restricted to a missing prefix",
+			trie: getTrie(3, 't'),
+			opts: DOTOptions{Prefix: "x"},
+			expected: `digraph trie {
+	node [shape=circle, label=""];
+	n0 [shape=point];
+}
+`,
+		},
+	}
+
+	for _, test := range dotTests {
+		checkValid(t, test.name, &test.trie)
+		var buf bytes.Buffer
+		if err := test.trie.WriteDOT(&buf, test.opts); err != nil {
+			t.Errorf("test '%s': unexpected error: %v", test.name, err)
+			continue
+		}
+		if buf.String() != test.expected {
+			t.Errorf("test '%s': expected\n%s\nbut was\n%s", test.name, test.expected, buf.String())
+		}
+	}
+}
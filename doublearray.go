@@ -0,0 +1,275 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strings"
+)
+
+// DoubleArray is a static trie of the entries of a Trie, compiled
+// into the BASE & CHECK arrays of a double-array trie. Each state is
+// an index into both arrays: the transition from state s on code c
+// goes to t = BASE[s] + c, which is only valid if CHECK[t] == s. So
+// each step of a lookup is a couple of array reads, rather than a
+// scan of the children of a Node and a pointer to follow, which
+// makes lookups much kinder to the cache.
+//
+// Codes are bytes plus one, with code 0 marking the end of a key (so
+// that keys may be prefixes of one another). Once only one key is
+// left below a state, the rest of it is kept in a separate tail
+// array rather than as a chain of states - a negative BASE points
+// into the tail. Keys are ordered as by string comparison.
+type DoubleArray struct {
+	base  []int32
+	check []int32
+	tail  []byte // each tail is its length (as a uvarint) then its bytes
+	count int
+}
+
+// doubleArrayCodes is the number of codes: one per byte, plus one to
+// mark the end of a key.
+const doubleArrayCodes = 257
+
+type doubleArrayBuilder struct {
+	da        *DoubleArray
+	keys      []string
+	firstFree int
+}
+
+// ToDoubleArray compiles the entries of the trie into a DoubleArray.
+// Data and weights are not kept. Case-insensitive tries cannot be
+// compiled (see ErrFolded), as their entries are not the terms to
+// be found.
+func (t *Trie) ToDoubleArray() (*DoubleArray, error) {
+
+	if t.fold {
+		return nil, ErrFolded
+	}
+
+	keys := t.Entries()
+	sort.Strings(keys)
+
+	b := doubleArrayBuilder{da: &DoubleArray{count: len(keys)}, keys: keys, firstFree: 1}
+	b.grow(1)
+	b.da.check[0] = 0
+	if len(keys) > 0 {
+		b.build(0, 0, len(keys), 0)
+	}
+	return b.da, nil
+}
+
+// grow extends the arrays to at least n states, all of them free.
+func (b *doubleArrayBuilder) grow(n int) {
+
+	for len(b.da.base) < n {
+		b.da.base = append(b.da.base, 0)
+		b.da.check = append(b.da.check, -1)
+	}
+}
+
+// build makes the states below s, for keys[lo:hi] which all share
+// their first depth bytes.
+func (b *doubleArrayBuilder) build(s int, lo int, hi int, depth int) {
+
+	if hi-lo == 1 && s != 0 {
+		b.setTail(s, b.keys[lo][depth:])
+		return
+	}
+
+	// The codes of the children, and the range of keys below each
+	type child struct{ code, lo, hi int }
+	var children []child
+	i := lo
+	if len(b.keys[i]) == depth {
+		children = append(children, child{0, i, i + 1})
+		i++
+	}
+	for i < hi {
+		c := b.keys[i][depth]
+		j := i + 1
+		for j < hi && b.keys[j][depth] == c {
+			j++
+		}
+		children = append(children, child{int(c) + 1, i, j})
+		i = j
+	}
+
+	base := b.findBase(children[0].code, func(base int) bool {
+		for _, c := range children {
+			if b.da.check[base+c.code] >= 0 {
+				return false
+			}
+		}
+		return true
+	}, children[len(children)-1].code)
+	b.da.base[s] = int32(base)
+
+	// Claim every child before building any of them
+	for _, c := range children {
+		b.da.check[base+c.code] = int32(s)
+	}
+	for b.firstFree < len(b.da.check) && b.da.check[b.firstFree] >= 0 {
+		b.firstFree++
+	}
+	for _, c := range children {
+		if c.code == 0 {
+			b.setTail(base, "")
+		} else {
+			b.build(base+c.code, c.lo, c.hi, depth+1)
+		}
+	}
+}
+
+// findBase returns the lowest base (from around the first free
+// state) at which fits accepts the children, growing the arrays so
+// that the highest code will fit.
+func (b *doubleArrayBuilder) findBase(first int, fits func(int) bool, last int) int {
+
+	base := max(1, b.firstFree-first)
+	for {
+		b.grow(base + last + 1)
+		if fits(base) {
+			return base
+		}
+		base++
+	}
+}
+
+func (b *doubleArrayBuilder) setTail(s int, tail string) {
+
+	b.da.base[s] = -int32(len(b.da.tail)) - 1
+	b.da.tail = binary.AppendUvarint(b.da.tail, uint64(len(tail)))
+	b.da.tail = append(b.da.tail, tail...)
+}
+
+// Count returns the number of entries in the double-array trie.
+func (da *DoubleArray) Count() int {
+	return da.count
+}
+
+// Size returns the number of states in the arrays (including the
+// free ones, which are the cost of packing them together).
+func (da *DoubleArray) Size() int {
+	return len(da.base)
+}
+
+// next returns the state reached from s on code c, or -1.
+func (da *DoubleArray) next(s int, c int) int {
+
+	t := int(da.base[s]) + c
+	if da.base[s] < 0 || t >= len(da.check) || int(da.check[t]) != s {
+		return -1
+	}
+	return t
+}
+
+// tailOf returns the tail of the leaf s, if it is one.
+func (da *DoubleArray) tailOf(s int) ([]byte, bool) {
+
+	if da.base[s] >= 0 {
+		return nil, false
+	}
+	i := int(-da.base[s] - 1)
+	l, n := binary.Uvarint(da.tail[i:])
+	return da.tail[i+n : i+n+int(l)], true
+}
+
+// Find is used to search for a specific term in the trie.
+func (da *DoubleArray) Find(s string) bool {
+
+	// Remove leading & trailing whitespace
+	trimmed := strings.TrimSpace(s)
+
+	// Sanity check (should catch empty strings too)
+	if len(trimmed) < 2 {
+		return false
+	}
+
+	state := 0
+	for i := 0; i < len(trimmed); i++ {
+		if tail, ok := da.tailOf(state); ok {
+			return string(tail) == trimmed[i:]
+		}
+		if state = da.next(state, int(trimmed[i])+1); state < 0 {
+			return false
+		}
+	}
+	if tail, ok := da.tailOf(state); ok {
+		return len(tail) == 0
+	}
+	return da.next(state, 0) >= 0
+}
+
+// WithPrefix returns every entry which begins with prefix, in sorted
+// order. As with Trie, the prefix is not trimmed.
+func (da *DoubleArray) WithPrefix(prefix string) []string {
+
+	entries := []string{}
+	if da.count == 0 {
+		return entries
+	}
+
+	state := 0
+	for i := 0; i < len(prefix); i++ {
+		if tail, ok := da.tailOf(state); ok {
+			if bytes.HasPrefix(tail, []byte(prefix[i:])) {
+				entries = append(entries, prefix[:i]+string(tail))
+			}
+			return entries
+		}
+		if state = da.next(state, int(prefix[i])+1); state < 0 {
+			return entries
+		}
+	}
+	da.collect(state, []byte(prefix), &entries)
+	return entries
+}
+
+func (da *DoubleArray) collect(s int, path []byte, entries *[]string) {
+
+	if tail, ok := da.tailOf(s); ok {
+		*entries = append(*entries, string(append(path, tail...)))
+		return
+	}
+	for c := 0; c < doubleArrayCodes; c++ {
+		t := da.next(s, c)
+		if t < 0 {
+			continue
+		}
+		if c == 0 {
+			*entries = append(*entries, string(path))
+		} else {
+			da.collect(t, append(path, byte(c-1)), entries)
+		}
+	}
+}
+
+// CommonPrefixSearch returns every entry which is a prefix of s
+// (including s itself), shortest first.
+func (da *DoubleArray) CommonPrefixSearch(s string) []string {
+
+	var entries []string
+	if da.count == 0 {
+		return entries
+	}
+
+	state := 0
+	for i := 0; ; i++ {
+		if tail, ok := da.tailOf(state); ok {
+			if strings.HasPrefix(s[i:], string(tail)) {
+				entries = append(entries, s[:i+len(tail)])
+			}
+			return entries
+		}
+		if da.next(state, 0) >= 0 {
+			entries = append(entries, s[:i])
+		}
+		if i == len(s) {
+			return entries
+		}
+		if state = da.next(state, int(s[i])+1); state < 0 {
+			return entries
+		}
+	}
+}
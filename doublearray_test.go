@@ -0,0 +1,217 @@
+package trie
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDoubleArray(t *testing.T) {
+
+	trie := getTrie(7, 'r')
+	for _, s := range []string{"slow", "slower", "slowly", "大豆", "黄豆", "大米"} {
+		trie.Insert(s)
+	}
+	da, err := trie.ToDoubleArray()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := trie.Entries()
+	slices.Sort(entries)
+	if found := da.WithPrefix(""); !reflect.DeepEqual(found, entries) {
+		t.Errorf("expected entries %v, but was %v", entries, found)
+	}
+	if da.Count() != trie.Count() {
+		t.Errorf("expected count to be %d, but was %d", trie.Count(), da.Count())
+	}
+
+	findTests := []struct {
+		value string
+		found bool
+	}{
+		{value: "romane", found: true},
+		{value: " slow\n", found: true},
+		{value: "slower", found: true},
+		{value: "rubicundus", found: true},
+		{value: "大米", found: true},
+		{value: "roman", found: false},
+		{value: "slowest", found: false},
+		{value: "slowerr", found: false},
+		{value: "大", found: false},
+		{value: "z", found: false},
+		{value: "", found: false},
+	}
+
+	for _, test := range findTests {
+		if found := da.Find(test.value); found != test.found {
+			t.Errorf("find '%s': expected found to be %t", test.value, test.found)
+		}
+	}
+
+	prefixTests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{prefix: "rub", expected: []string{"rubens", "ruber", "rubicon", "rubicundus"}},
+		{prefix: "slow", expected: []string{"slow", "slower", "slowly"}},
+		{prefix: "slowl", expected: []string{"slowly"}},
+		{prefix: "rubicun", expected: []string{"rubicundus"}},
+		{prefix: "rubicunx", expected: []string{}},
+		{prefix: "大", expected: []string{"大米", "大豆"}},
+		{prefix: "x", expected: []string{}},
+	}
+
+	for _, test := range prefixTests {
+		if found := da.WithPrefix(test.prefix); !reflect.DeepEqual(found, test.expected) {
+			t.Errorf("prefix '%s': expected %v, but was %v", test.prefix, test.expected, found)
+		}
+	}
+
+	commonTests := []struct {
+		value    string
+		expected []string
+	}{
+		{value: "slowly", expected: []string{"slow", "slowly"}},
+		{value: "slowerness", expected: []string{"slow", "slower"}},
+		{value: "romanesque", expected: []string{"romane"}},
+		{value: "rubicundusque", expected: []string{"rubicundus"}},
+		{value: "rubicund", expected: nil},
+		{value: "大米饭", expected: []string{"大米"}},
+		{value: "", expected: nil},
+	}
+
+	for _, test := range commonTests {
+		if found := da.CommonPrefixSearch(test.value); !reflect.DeepEqual(found, test.expected) {
+			t.Errorf("common prefixes of '%s': expected %v, but was %v", test.value, test.expected, found)
+		}
+	}
+}
+
+func TestDoubleArrayEmpty(t *testing.T) {
+
+	trie := NewTrie()
+	da, err := trie.ToDoubleArray()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if da.Count() != 0 || len(da.WithPrefix("")) != 0 || da.Find("ab") || da.CommonPrefixSearch("ab") != nil {
+		t.Errorf("expected an empty double-array trie")
+	}
+}
+
+func TestDoubleArrayFolded(t *testing.T) {
+
+	trie := NewFoldedTrie()
+	trie.Insert("iPhone")
+	trie.Insert("IPHONE")
+	if da, err := trie.ToDoubleArray(); err != ErrFolded || da != nil {
+		t.Errorf("expected error to be %v, but was %v", ErrFolded, err)
+	}
+}
+
+func TestDoubleArrayLarge(t *testing.T) {
+
+	trie := NewTrie()
+	for _, s := range benchmarkKeys {
+		trie.Insert(s)
+	}
+	da, err := trie.ToDoubleArray()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, s := range benchmarkKeys {
+		if !da.Find(s) {
+			t.Fatalf("expected to find '%s'", s)
+		}
+		if da.Find(s + "x") {
+			t.Fatalf("expected not to find '%sx'", s)
+		}
+		if found := da.CommonPrefixSearch(s + "x"); len(found) == 0 || found[len(found)-1] != s {
+			t.Fatalf("expected '%s' to be the longest common prefix of '%sx', but was %v", s, s, found)
+		}
+	}
+	var expected []string
+	for _, s := range benchmarkKeys {
+		if strings.HasPrefix(s, "cka") || strings.HasPrefix(s, "fq") {
+			expected = append(expected, s)
+		}
+	}
+	if found := append(da.WithPrefix("cka"), da.WithPrefix("fq")...); !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected %d entries with the prefixes, but was %d", len(expected), len(found))
+	}
+
+	// With the tails compressed, there should be fewer states than
+	// bytes in the keys
+	bytes := 0
+	for _, s := range benchmarkKeys {
+		bytes += len(s)
+	}
+	if da.Size() >= bytes {
+		t.Errorf("expected fewer than %d states, but there were %d", bytes, da.Size())
+	}
+}
+
+func BenchmarkFindNodeLarge(b *testing.B) {
+
+	trie := NewTrie()
+	for _, s := range benchmarkKeys {
+		trie.Insert(s)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		benchmarkN = trie.findNode(benchmarkKeys[i%len(benchmarkKeys)])
+	}
+}
+
+func BenchmarkFindDoubleArrayLarge(b *testing.B) {
+
+	trie := NewTrie()
+	for _, s := range benchmarkKeys {
+		trie.Insert(s)
+	}
+	da, err := trie.ToDoubleArray()
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		benchmarkFound = da.Find(benchmarkKeys[i%len(benchmarkKeys)])
+	}
+}
+
+func BenchmarkFindNodeWide(b *testing.B) {
+
+	keys := wideKeys()
+	trie := NewTrie()
+	for _, s := range keys {
+		trie.Insert(s)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		benchmarkN = trie.findNode(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkFindDoubleArrayWide(b *testing.B) {
+
+	keys := wideKeys()
+	trie := NewTrie()
+	for _, s := range keys {
+		trie.Insert(s)
+	}
+	da, err := trie.ToDoubleArray()
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		benchmarkFound = da.Find(keys[i%len(keys)])
+	}
+}
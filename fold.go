@@ -0,0 +1,81 @@
+package trie
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NewFoldedTrie is used to create a new case-insensitive radix
+// trie. Terms are stored with each rune folded (using the simple
+// case folding of unicode.SimpleFold) so that "IPHONE" will find
+// "iPhone", while each entry node remembers the original terms
+// which were inserted for it (see Node.Keys), which are what
+// Entries, WithPrefix and WithSuffix return.
+//
+// Simple folding maps one rune to one rune, so 'ß' matches 'ẞ'
+// but not "ss", and the Turkish dotted & dotless i are distinct
+// from 'i' and 'I'. As the folded term is the entry, its data and
+// weight are shared by all of its original terms, and Delete
+// removes them all. Walk and the other features which work on
+// the nodes directly (such as Matcher and Speller) see only the
+// folded terms. The binary and JSON encodings keep the folding
+// and the original terms, but the read-only forms which cannot
+// (the mapped layout, DAWG, FST, LOUDSTrie and DoubleArray)
+// return ErrFolded instead.
+func NewFoldedTrie() Trie {
+	return Trie{fold: true}
+}
+
+// ErrFolded is returned when a case-insensitive trie is converted
+// into a form which can hold neither the folding nor the original
+// terms.
+var ErrFolded = errors.New("trie: case-insensitive trie cannot be converted")
+
+// foldKey returns s with each rune folded, if the trie is case
+// insensitive.
+func (t *Trie) foldKey(s string) string {
+
+	if !t.fold {
+		return s
+	}
+	return strings.Map(foldRune, s)
+}
+
+// foldRune returns the smallest rune which is equivalent to r
+// under simple case folding.
+func foldRune(r rune) rune {
+
+	folded := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < folded {
+			folded = f
+		}
+	}
+	return folded
+}
+
+// unfoldOffset converts end, a byte offset into the folded form
+// of s, into the equivalent byte offset into s itself (a folded
+// rune is not always the same length as the original).
+func unfoldOffset(s string, end int) int {
+
+	i, folded := 0, 0
+	for folded < end && i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		folded += utf8.RuneLen(foldRune(r))
+		i += size
+	}
+	return i
+}
+
+// appendKeys appends the term for an entry to entries - or its
+// original terms, if the trie is case-insensitive.
+func (t *Trie) appendKeys(entries []string, s string, n *Node) []string {
+
+	if t.fold {
+		return append(entries, n.keys...)
+	}
+	return append(entries, s)
+}
@@ -0,0 +1,103 @@
+package trie
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func getFoldedTrie() Trie {
+
+	trie := NewFoldedTrie()
+	for _, s := range []string{"iPhone", "IPHONE", "iPad", "Straße", "Kelvin", "Iğdır", "大豆"} {
+		trie.Insert(s)
+	}
+	return trie
+}
+
+func TestFoldedFind(t *testing.T) {
+
+	trie := getFoldedTrie()
+	checkValid(t, "folded", &trie)
+
+	if trie.Count() != 6 {
+		t.Errorf("expected count to be 6, but was %d", trie.Count())
+	}
+	if trie.Insert("iphone") != true || trie.Insert("iPhone") != false {
+		t.Errorf("expected a new spelling to be inserted, but not a repeated one")
+	}
+
+	findTests := []struct {
+		name     string
+		value    string
+		expected bool
+		keys     []string
+	}{
+		{name: "exact case", value: "iPad", expected: true, keys: []string{"iPad"}},
+		{name: "upper case", value: "IPAD", expected: true, keys: []string{"iPad"}},
+		{name: "several spellings", value: "iphone", expected: true, keys: []string{"iPhone", "IPHONE", "iphone"}},
+		{name: "sharp s", value: "STRAẞE", expected: true, keys: []string{"Straße"}},
+		{name: "sharp s is not ss", value: "STRASSE", expected: false},
+		{name: "kelvin sign", value: "kelvin", expected: true, keys: []string{"Kelvin"}},
+		{name: "turkish dotless i is distinct", value: "IĞDIR", expected: false},
+		{name: "turkish dotted i is distinct", value: "İğdır", expected: false},
+		{name: "turkish letters", value: "iĞDıR", expected: true, keys: []string{"Iğdır"}},
+		{name: "chinese", value: "大豆", expected: true, keys: []string{"大豆"}},
+		{name: "prefix is not an entry", value: "IP", expected: false},
+	}
+
+	for _, test := range findTests {
+		found, n := trie.Find(test.value)
+		if found != test.expected {
+			t.Errorf("test '%s': expected found to be %t", test.name, test.expected)
+			continue
+		}
+		if found && !reflect.DeepEqual(n.Keys(), test.keys) {
+			t.Errorf("test '%s': expected keys %v, but was %v", test.name, test.keys, n.Keys())
+		}
+	}
+}
+
+func TestFoldedCompletions(t *testing.T) {
+
+	for _, indexed := range []bool{false, true} {
+		trie := getFoldedTrie()
+		if indexed {
+			trie.IndexSuffixes()
+		}
+		trie.InsertData("IPOD", "music")
+		checkValid(t, "folded", &trie)
+
+		entries := trie.WithPrefix("ip")
+		slices.Sort(entries)
+		if expected := []string{"IPHONE", "IPOD", "iPad", "iPhone"}; !reflect.DeepEqual(entries, expected) {
+			t.Errorf("indexed %t: expected completions %v, but was %v", indexed, expected, entries)
+		}
+
+		entries = trie.WithSuffix("ONE")
+		slices.Sort(entries)
+		if expected := []string{"IPHONE", "iPhone"}; !reflect.DeepEqual(entries, expected) {
+			t.Errorf("indexed %t: expected suffix matches %v, but was %v", indexed, expected, entries)
+		}
+
+		if _, n := trie.Find("ipod"); n == nil || n.Data() != "music" {
+			t.Errorf("indexed %t: expected data to be stored for the folded entry", indexed)
+		}
+
+		// The Kelvin sign is longer than the 'K' it folds to
+		if longest, found := trie.LongestPrefix("KELVINS"); !found || longest != "KELVIN" {
+			t.Errorf("indexed %t: expected longest prefix 'KELVIN', but was '%s'", indexed, longest)
+		}
+		if longest, found := trie.LongestSuffix("my IPAD"); !found || longest != "IPAD" {
+			t.Errorf("indexed %t: expected longest suffix 'IPAD', but was '%s'", indexed, longest)
+		}
+
+		if !trie.Delete("IPHONE") || trie.Delete("iphone") {
+			t.Errorf("indexed %t: expected every spelling to be deleted at once", indexed)
+		}
+		checkValid(t, "folded delete", &trie)
+		if entries := trie.Entries(); len(entries) != 6 {
+			t.Errorf("indexed %t: expected 6 entries after delete, but was %v", indexed, entries)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package trie
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"sort"
+)
+
+// ErrNegativeOutput is returned by BuildFST for entries with negative
+// weights, which cannot be used as outputs.
+var ErrNegativeOutput = errors.New("trie: negative output")
+
+// FST is a read-only finite state transducer mapping keys to integer
+// outputs, as made by an FSTBuilder. Like a DAWG it shares equivalent
+// states, but as each key has its own output the outputs are spread
+// along the arcs - each arc carrying as much of the output as all of
+// the keys which pass along it have in common - so the output of a
+// key is the sum of those on its path (plus that of its final state).
+// Arcs are labelled with bytes, so keys are ordered as by string
+// comparison.
+type FST struct {
+	final    []bool
+	finalOut []uint64
+	first    []int32 // the arcs of state i are first[i]:first[i+1]
+	labels   []byte
+	outs     []uint64
+	targets  []int32
+	start    int32
+	count    int
+}
+
+// FSTBuilder builds a minimal FST from keys which are added in sorted
+// order. States are frozen (and shared with any equivalent state) as
+// soon as no later key can reach them.
+type FSTBuilder struct {
+	fst      *FST
+	stack    []*fstNode // the unfrozen path to the last key
+	last     string
+	started  bool
+	registry map[string]int32
+	key      []byte
+}
+
+// fstNode is an unfrozen state, whose last arc (if any) is still
+// on the path being built and so does not have a target yet.
+type fstNode struct {
+	final    bool
+	finalOut uint64
+	arcs     []fstArc
+}
+
+type fstArc struct {
+	label  byte
+	out    uint64
+	target int32
+}
+
+// NewFSTBuilder returns an FSTBuilder for an empty FST.
+func NewFSTBuilder() *FSTBuilder {
+
+	return &FSTBuilder{
+		fst:      &FST{first: []int32{0}},
+		stack:    []*fstNode{{}},
+		registry: map[string]int32{},
+	}
+}
+
+// Add adds key with the given output. Keys must be added in sorted
+// order, without duplicates.
+func (b *FSTBuilder) Add(key string, out uint64) error {
+
+	if b.started {
+		if key == b.last {
+			return fmt.Errorf("%w: %q", ErrDuplicateKey, key)
+		}
+		if key < b.last {
+			return fmt.Errorf("%w: %q follows %q", ErrUnsorted, key, b.last)
+		}
+	}
+
+	p := 0
+	for p < len(key) && p < len(b.last) && key[p] == b.last[p] {
+		p++
+	}
+	b.freeze(p + 1)
+
+	// Along the shared prefix, each arc keeps only what it has in
+	// common with the new output, pushing the rest further on
+	for i := 0; i < p; i++ {
+		arc := &b.stack[i].arcs[len(b.stack[i].arcs)-1]
+		common := min(arc.out, out)
+		if rest := arc.out - common; rest > 0 {
+			next := b.stack[i+1]
+			for j := range next.arcs {
+				next.arcs[j].out += rest
+			}
+			if next.final {
+				next.finalOut += rest
+			}
+		}
+		arc.out = common
+		out -= common
+	}
+
+	if p == len(key) {
+		// Only the empty key, added first, can end here
+		b.stack[p].final = true
+		b.stack[p].finalOut = out
+	} else {
+		b.stack[p].arcs = append(b.stack[p].arcs, fstArc{label: key[p], out: out})
+		for i := p + 1; i <= len(key); i++ {
+			n := &fstNode{}
+			if i < len(key) {
+				n.arcs = []fstArc{{label: key[i]}}
+			}
+			b.stack = append(b.stack, n)
+		}
+		b.stack[len(key)].final = true
+	}
+
+	b.last = key
+	b.started = true
+	b.fst.count++
+	return nil
+}
+
+// freeze compiles the unfrozen states beyond depth, from the deepest
+// up, setting the targets of the arcs which lead to them.
+func (b *FSTBuilder) freeze(depth int) {
+
+	for len(b.stack) > depth {
+		n := b.stack[len(b.stack)-1]
+		b.stack = b.stack[:len(b.stack)-1]
+		parent := b.stack[len(b.stack)-1]
+		parent.arcs[len(parent.arcs)-1].target = b.compile(n)
+	}
+}
+
+// compile returns the frozen state equivalent to n, making it only if
+// there is no such state already.
+func (b *FSTBuilder) compile(n *fstNode) int32 {
+
+	b.key = b.key[:0]
+	if n.final {
+		b.key = append(b.key, 1)
+		b.key = binary.AppendUvarint(b.key, n.finalOut)
+	} else {
+		b.key = append(b.key, 0)
+	}
+	for _, a := range n.arcs {
+		b.key = append(b.key, a.label)
+		b.key = binary.AppendUvarint(b.key, a.out)
+		b.key = binary.AppendUvarint(b.key, uint64(a.target))
+	}
+	if s, ok := b.registry[string(b.key)]; ok {
+		return s
+	}
+
+	f := b.fst
+	s := int32(len(f.final))
+	f.final = append(f.final, n.final)
+	f.finalOut = append(f.finalOut, n.finalOut)
+	for _, a := range n.arcs {
+		f.labels = append(f.labels, a.label)
+		f.outs = append(f.outs, a.out)
+		f.targets = append(f.targets, a.target)
+	}
+	f.first = append(f.first, int32(len(f.labels)))
+	b.registry[string(b.key)] = s
+	return s
+}
+
+// Finish freezes the remaining states and returns the FST. The
+// builder may not be used afterwards.
+func (b *FSTBuilder) Finish() *FST {
+
+	b.freeze(1)
+	b.fst.start = b.compile(b.stack[0])
+	f := b.fst
+	*b = FSTBuilder{}
+	return f
+}
+
+// BuildFST builds an FST of the entries of the trie, whose outputs
+// are their weights (see InsertWeight). Case-insensitive tries
+// cannot be built (see ErrFolded), as the FST would hold only their
+// folded terms.
+func (t *Trie) BuildFST() (*FST, error) {
+
+	if t.fold {
+		return nil, ErrFolded
+	}
+
+	type entry struct {
+		key    string
+		weight int
+	}
+	entries := make([]entry, 0, t.count)
+	var err error
+	t.Walk(func(s string, n *Node) bool {
+		if n.weight < 0 {
+			err = fmt.Errorf("%w: %q has weight %d", ErrNegativeOutput, s, n.weight)
+			return false
+		}
+		entries = append(entries, entry{key: s, weight: n.weight})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	b := NewFSTBuilder()
+	for _, e := range entries {
+		if err := b.Add(e.key, uint64(e.weight)); err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish(), nil
+}
+
+// Count returns the number of keys in the FST.
+func (f *FST) Count() int {
+	return f.count
+}
+
+// States returns the number of states in the FST.
+func (f *FST) States() int {
+	return len(f.final)
+}
+
+// arc returns the index of the arc from s labelled c, or -1.
+func (f *FST) arc(s int32, c byte) int {
+
+	lo, hi := int(f.first[s]), int(f.first[s+1])
+	i := lo + sort.Search(hi-lo, func(i int) bool { return f.labels[lo+i] >= c })
+	if i < hi && f.labels[i] == c {
+		return i
+	}
+	return -1
+}
+
+// Get returns the output for key, if it is in the FST.
+func (f *FST) Get(key string) (uint64, bool) {
+
+	s, out := f.start, uint64(0)
+	for i := 0; i < len(key); i++ {
+		a := f.arc(s, key[i])
+		if a < 0 {
+			return 0, false
+		}
+		out += f.outs[a]
+		s = f.targets[a]
+	}
+	if !f.final[s] {
+		return 0, false
+	}
+	return out + f.finalOut[s], true
+}
+
+// All returns every key in the FST with its output, in sorted order.
+func (f *FST) All() iter.Seq2[string, uint64] {
+	return f.Range("", "")
+}
+
+// Prefix returns every key in the FST which begins with prefix, with
+// its output, in sorted order.
+func (f *FST) Prefix(prefix string) iter.Seq2[string, uint64] {
+
+	return func(yield func(string, uint64) bool) {
+		s, out := f.start, uint64(0)
+		for i := 0; i < len(prefix); i++ {
+			a := f.arc(s, prefix[i])
+			if a < 0 {
+				return
+			}
+			out += f.outs[a]
+			s = f.targets[a]
+		}
+		f.visit(s, []byte(prefix), out, "", "", yield)
+	}
+}
+
+// Range returns every key in the FST from from (inclusive) up to to
+// (exclusive), with its output, in sorted order. An empty to means
+// that there is no upper bound.
+func (f *FST) Range(from string, to string) iter.Seq2[string, uint64] {
+
+	return func(yield func(string, uint64) bool) {
+		f.visit(f.start, []byte{}, 0, from, to, yield)
+	}
+}
+
+// visit yields the keys reachable from s (which is reached by path,
+// with output out) which fall within from and to, depth first so
+// that they are in sorted order. Arcs are skipped when every key
+// through them is out of range.
+func (f *FST) visit(s int32, path []byte, out uint64, from string, to string, yield func(string, uint64) bool) bool {
+
+	if f.final[s] {
+		key := string(path)
+		if key >= from && (to == "" || key < to) && !yield(key, out+f.finalOut[s]) {
+			return false
+		}
+	}
+	for a := f.first[s]; a < f.first[s+1]; a++ {
+		next := append(path, f.labels[a])
+		if to != "" && string(next) >= to {
+			// So is every key through this arc, and the later ones
+			break
+		}
+		if string(next) < from[:min(len(from), len(next))] {
+			continue
+		}
+		if !f.visit(f.targets[a], next, out+f.outs[a], from, to, yield) {
+			return false
+		}
+	}
+	return true
+}
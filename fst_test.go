@@ -0,0 +1,184 @@
+package trie
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type fstPair struct {
+	key string
+	out uint64
+}
+
+func collectFST(seq func(func(string, uint64) bool)) []fstPair {
+
+	pairs := []fstPair{}
+	for k, v := range seq {
+		pairs = append(pairs, fstPair{key: k, out: v})
+	}
+	return pairs
+}
+
+func TestFSTBuilder(t *testing.T) {
+
+	b := NewFSTBuilder()
+	for _, p := range []fstPair{{"cat", 5}, {"dog", 7}, {"dogs", 13}, {"hat", 5}} {
+		if err := b.Add(p.key, p.out); err != nil {
+			t.Fatalf("add '%s': unexpected error: %v", p.key, err)
+		}
+	}
+	if err := b.Add("dog", 1); !errors.Is(err, ErrUnsorted) {
+		t.Errorf("expected an unsorted error, but was %v", err)
+	}
+	if err := b.Add("hat", 1); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("expected a duplicate error, but was %v", err)
+	}
+	f := b.Finish()
+
+	if f.Count() != 4 {
+		t.Errorf("expected count to be 4, but was %d", f.Count())
+	}
+
+	// root; then "c" & "h" lead to the same state (as their outputs
+	// are on the first arc), then on to "a" & "t"; then "d", "o" &
+	// "g" - from which "s" leads to the same final state as "t"
+	if f.States() != 7 {
+		t.Errorf("expected 7 states, but was %d", f.States())
+	}
+
+	getTests := []struct {
+		key   string
+		out   uint64
+		found bool
+	}{
+		{key: "cat", out: 5, found: true},
+		{key: "dog", out: 7, found: true},
+		{key: "dogs", out: 13, found: true},
+		{key: "hat", out: 5, found: true},
+		{key: "do", found: false},
+		{key: "dogsled", found: false},
+		{key: "", found: false},
+	}
+
+	for _, test := range getTests {
+		out, found := f.Get(test.key)
+		if found != test.found || out != test.out {
+			t.Errorf("get '%s': expected (%d, %t), but was (%d, %t)", test.key, test.out, test.found, out, found)
+		}
+	}
+
+	if pairs := collectFST(f.Prefix("do")); !reflect.DeepEqual(pairs, []fstPair{{"dog", 7}, {"dogs", 13}}) {
+		t.Errorf("expected prefix 'do' pairs, but was %v", pairs)
+	}
+	if pairs := collectFST(f.Range("ca", "dogs")); !reflect.DeepEqual(pairs, []fstPair{{"cat", 5}, {"dog", 7}}) {
+		t.Errorf("expected range pairs, but was %v", pairs)
+	}
+	if pairs := collectFST(f.Range("d", "")); !reflect.DeepEqual(pairs, []fstPair{{"dog", 7}, {"dogs", 13}, {"hat", 5}}) {
+		t.Errorf("expected unbounded range pairs, but was %v", pairs)
+	}
+
+	// Stopping early
+	for k := range f.All() {
+		if k != "cat" {
+			t.Errorf("expected the first key to be 'cat', but was '%s'", k)
+		}
+		break
+	}
+}
+
+func TestFSTEmptyKey(t *testing.T) {
+
+	b := NewFSTBuilder()
+	b.Add("", 3)
+	b.Add("a", 4)
+	f := b.Finish()
+
+	if out, found := f.Get(""); !found || out != 3 {
+		t.Errorf("expected the empty key to have output 3, but was (%d, %t)", out, found)
+	}
+	if out, found := f.Get("a"); !found || out != 4 {
+		t.Errorf("expected 'a' to have output 4, but was (%d, %t)", out, found)
+	}
+}
+
+func TestFSTMatchesMap(t *testing.T) {
+
+	m := map[string]uint64{}
+	for i, k := range benchmarkKeys {
+		m[k] = uint64((i * 7919) % 1000)
+	}
+	m["大豆"] = 42
+	m["大豆油"] = 40
+
+	b := NewFSTBuilder()
+	keys := slices.Sorted(maps.Keys(m))
+	for _, k := range keys {
+		if err := b.Add(k, m[k]); err != nil {
+			t.Fatalf("add '%s': unexpected error: %v", k, err)
+		}
+	}
+	f := b.Finish()
+
+	for _, k := range keys {
+		if out, found := f.Get(k); !found || out != m[k] {
+			t.Errorf("get '%s': expected %d, but was (%d, %t)", k, m[k], out, found)
+		}
+	}
+
+	for _, bounds := range [][2]string{{"", ""}, {"bk", "cm"}, {"ckaing", "cla"}, {"j", ""}, {"大", "大豆油"}, {"z", "a"}} {
+		expected := []fstPair{}
+		for _, k := range keys {
+			if k >= bounds[0] && (bounds[1] == "" || k < bounds[1]) {
+				expected = append(expected, fstPair{k, m[k]})
+			}
+		}
+		if pairs := collectFST(f.Range(bounds[0], bounds[1])); !reflect.DeepEqual(pairs, expected) {
+			t.Errorf("range %v: expected %d pairs, but was %d", bounds, len(expected), len(pairs))
+		}
+	}
+
+	for _, prefix := range []string{"", "c", "dle", "fqi", "大", "x"} {
+		expected := []fstPair{}
+		for _, k := range keys {
+			if strings.HasPrefix(k, prefix) {
+				expected = append(expected, fstPair{k, m[k]})
+			}
+		}
+		if pairs := collectFST(f.Prefix(prefix)); !reflect.DeepEqual(pairs, expected) {
+			t.Errorf("prefix '%s': expected %d pairs, but was %d", prefix, len(expected), len(pairs))
+		}
+	}
+}
+
+func TestBuildFST(t *testing.T) {
+
+	trie := NewTrie()
+	trie.InsertWeight("slow", 3)
+	trie.InsertWeight("slower", 2)
+	trie.InsertWeight("slowly", 5)
+	trie.Insert("rubicon")
+
+	f, err := trie.BuildFST()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []fstPair{{"rubicon", 0}, {"slow", 3}, {"slower", 2}, {"slowly", 5}}
+	if pairs := collectFST(f.All()); !reflect.DeepEqual(pairs, expected) {
+		t.Errorf("expected %v, but was %v", expected, pairs)
+	}
+
+	trie.InsertWeight("slowest", -1)
+	if _, err := trie.BuildFST(); !errors.Is(err, ErrNegativeOutput) {
+		t.Errorf("expected a negative output error, but was %v", err)
+	}
+
+	folded := NewFoldedTrie()
+	folded.InsertWeight("iPhone", 3)
+	if _, err := folded.BuildFST(); err != ErrFolded {
+		t.Errorf("expected error to be %v, but was %v", ErrFolded, err)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/mramshaw/radix-trie
+
+go 1.23
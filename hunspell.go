@@ -0,0 +1,365 @@
+package trie
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LoadHunspell populates the trie from a Hunspell dictionary: the
+// word list in dic and the affix rules in aff. Every stem is added
+// along with each surface form generated from it by its prefix and
+// suffix rules (including combined prefix and suffix forms where
+// both rules allow cross products).
+//
+// Only a subset of the affix file format is supported: the SET
+// (UTF-8 or ISO8859-1), FLAG (single characters, UTF-8, long or
+// num) and PFX/SFX directives. Continuation classes on affixes
+// and every other directive are ignored. Generated forms which
+// are too short, or which duplicate other forms, are skipped
+// silently, while stems which are too short (ErrInvalidKey) or
+// which are already present (ErrDuplicateKey) are reported.
+func (t *Trie) LoadHunspell(dic io.Reader, aff io.Reader) (LoadReport, error) {
+
+	report := LoadReport{}
+
+	a, err := parseAffixes(aff)
+	if err != nil {
+		return report, err
+	}
+
+	scanner := bufio.NewScanner(dic)
+	scanner.Buffer(nil, 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		// The first line holds the (approximate) number of words
+		if line == 1 {
+			if _, err := strconv.Atoi(strings.TrimSpace(text)); err == nil {
+				continue
+			}
+		}
+
+		decoded, err := a.decode(text)
+		if err != nil {
+			report.Rejected = append(report.Rejected, RejectedLine{Line: line, Text: text, Err: err})
+			continue
+		}
+		stem, flags := splitDicLine(decoded)
+		if stem == "" {
+			continue
+		}
+		if len(stem) < 2 || utf8.RuneCountInString(stem) < 2 {
+			report.Rejected = append(report.Rejected, RejectedLine{Line: line, Text: text, Err: ErrInvalidKey})
+		} else if !t.Insert(stem) {
+			report.Rejected = append(report.Rejected, RejectedLine{Line: line, Text: text, Err: ErrDuplicateKey})
+		} else {
+			report.Loaded++
+		}
+
+		for _, form := range a.expand(stem, a.parseFlags(flags)) {
+			if t.Insert(form) {
+				report.Loaded++
+			}
+		}
+	}
+	return report, scanner.Err()
+}
+
+// splitDicLine splits a line of a .dic file into its stem and its
+// flags, dropping any morphological fields which follow them.
+func splitDicLine(line string) (string, string) {
+
+	if i := strings.IndexAny(line, "\t "); i >= 0 {
+		line = line[:i]
+	}
+
+	// A slash may be escaped to form part of the stem
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) && line[i+1] == '/' {
+			line = line[:i] + line[i+1:]
+			continue
+		}
+		if line[i] == '/' {
+			return line[:i], line[i+1:]
+		}
+	}
+	return line, ""
+}
+
+type affixRule struct {
+	strip     string
+	add       string
+	condition []charClass
+}
+
+type affixClass struct {
+	prefix bool
+	cross  bool
+	rules  []affixRule
+}
+
+type affixes struct {
+	latin1   bool
+	flagType string
+	classes  map[string]*affixClass
+}
+
+func parseAffixes(r io.Reader) (*affixes, error) {
+
+	a := &affixes{classes: map[string]*affixClass{}}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text, err := a.decode(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("trie: affix line %d: %w", line, err)
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch fields[0] {
+		case "SET":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("trie: affix line %d: missing encoding", line)
+			}
+			switch strings.ToUpper(fields[1]) {
+			case "UTF-8":
+				a.latin1 = false
+			case "ISO8859-1":
+				a.latin1 = true
+			default:
+				return nil, fmt.Errorf("trie: affix line %d: unsupported encoding %q", line, fields[1])
+			}
+		case "FLAG":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("trie: affix line %d: missing flag type", line)
+			}
+			a.flagType = fields[1]
+		case "PFX", "SFX":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("trie: affix line %d: too few fields", line)
+			}
+			class, ok := a.classes[fields[1]]
+			if !ok {
+				// This is the header: PFX flag cross_product count
+				a.classes[fields[1]] = &affixClass{prefix: fields[0] == "PFX", cross: fields[2] == "Y"}
+				continue
+			}
+			if len(fields) < 5 {
+				return nil, fmt.Errorf("trie: affix line %d: too few fields", line)
+			}
+			rule, err := parseAffixRule(fields[2], fields[3], fields[4])
+			if err != nil {
+				return nil, fmt.Errorf("trie: affix line %d: %w", line, err)
+			}
+			class.rules = append(class.rules, rule)
+		}
+	}
+	return a, scanner.Err()
+}
+
+func parseAffixRule(strip string, add string, condition string) (affixRule, error) {
+
+	if strip == "0" {
+		strip = ""
+	}
+	// Continuation classes are not supported
+	if i := strings.IndexByte(add, '/'); i >= 0 {
+		add = add[:i]
+	}
+	if add == "0" {
+		add = ""
+	}
+	cond, err := parseCondition(condition)
+	if err != nil {
+		return affixRule{}, err
+	}
+	return affixRule{strip: strip, add: add, condition: cond}, nil
+}
+
+// decode converts a line from the dictionary's encoding to UTF-8.
+func (a *affixes) decode(s string) (string, error) {
+
+	if !a.latin1 {
+		if !utf8.ValidString(s) {
+			return "", ErrInvalidUTF8
+		}
+		return s, nil
+	}
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes), nil
+}
+
+// parseFlags splits the flags of a stem according to the FLAG type.
+func (a *affixes) parseFlags(s string) []string {
+
+	// Lines have already been decoded to UTF-8, so flags (other
+	// than numbers) are split by rune rather than by byte
+	var flags []string
+	switch a.flagType {
+	case "long":
+		runes := []rune(s)
+		for i := 0; i+1 < len(runes); i += 2 {
+			flags = append(flags, string(runes[i:i+2]))
+		}
+	case "num":
+		for _, f := range strings.Split(s, ",") {
+			if f != "" {
+				flags = append(flags, f)
+			}
+		}
+	default:
+		for _, r := range s {
+			flags = append(flags, string(r))
+		}
+	}
+	return flags
+}
+
+// expand returns the surface forms generated from stem by the
+// affix classes named in flags.
+func (a *affixes) expand(stem string, flags []string) []string {
+
+	var forms []string
+	var prefixes, suffixes []affixRule
+
+	for _, f := range flags {
+		class, ok := a.classes[f]
+		if !ok {
+			continue
+		}
+		for _, rule := range class.rules {
+			var form string
+			if class.prefix {
+				form, ok = rule.applyPrefix(stem)
+			} else {
+				form, ok = rule.applySuffix(stem)
+			}
+			if !ok {
+				continue
+			}
+			forms = append(forms, form)
+			if class.cross && class.prefix {
+				prefixes = append(prefixes, rule)
+			} else if class.cross {
+				suffixes = append(suffixes, rule)
+			}
+		}
+	}
+
+	// Cross products apply a prefix and a suffix which could each
+	// have been applied to the stem alone
+	for _, p := range prefixes {
+		for _, sfx := range suffixes {
+			if len(p.strip)+len(sfx.strip) >= len(stem) {
+				continue
+			}
+			forms = append(forms, p.add+stem[len(p.strip):len(stem)-len(sfx.strip)]+sfx.add)
+		}
+	}
+	return forms
+}
+
+func (r affixRule) applySuffix(stem string) (string, bool) {
+
+	if !strings.HasSuffix(stem, r.strip) || len(stem) == len(r.strip) {
+		return "", false
+	}
+	if !matchCondition(r.condition, []rune(stem), false) {
+		return "", false
+	}
+	return stem[:len(stem)-len(r.strip)] + r.add, true
+}
+
+func (r affixRule) applyPrefix(stem string) (string, bool) {
+
+	if !strings.HasPrefix(stem, r.strip) || len(stem) == len(r.strip) {
+		return "", false
+	}
+	if !matchCondition(r.condition, []rune(stem), true) {
+		return "", false
+	}
+	return r.add + stem[len(r.strip):], true
+}
+
+// charClass matches a single rune of an affix condition.
+type charClass struct {
+	any    bool
+	negate bool
+	runes  string
+}
+
+func (c charClass) match(r rune) bool {
+
+	if c.any {
+		return true
+	}
+	return strings.ContainsRune(c.runes, r) != c.negate
+}
+
+// parseCondition parses an affix condition, which is a sequence of
+// runes, '.' (any rune) and bracketed (possibly negated) classes.
+func parseCondition(s string) ([]charClass, error) {
+
+	if s == "." {
+		return nil, nil
+	}
+	var cond []charClass
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '.':
+			cond = append(cond, charClass{any: true})
+			i++
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated condition %q", s)
+			}
+			class := s[i+1 : i+end]
+			negate := strings.HasPrefix(class, "^")
+			if negate {
+				class = class[1:]
+			}
+			cond = append(cond, charClass{negate: negate, runes: class})
+			i += end + 1
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			cond = append(cond, charClass{runes: string(r)})
+			i += size
+		}
+	}
+	return cond, nil
+}
+
+// matchCondition checks the condition against the start of the
+// stem (for prefixes) or against its end (for suffixes).
+func matchCondition(cond []charClass, stem []rune, prefix bool) bool {
+
+	if len(cond) > len(stem) {
+		return false
+	}
+	offset := 0
+	if !prefix {
+		offset = len(stem) - len(cond)
+	}
+	for i, c := range cond {
+		if !c.match(stem[offset+i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,176 @@
+package trie
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadHunspell(t *testing.T) {
+
+	hunspellTests := []struct {
+		name     string
+		dic      string
+		aff      string
+		expected []string
+		rejected []RejectedLine
+	}{
+		{
+			name: "english sample",
+			dic:  "testdata/sample.dic",
+			aff:  "testdata/sample.aff",
+			expected: []string{
+				"box", "boxes",
+				"carried", "carries", "carry", "carrying",
+				"do",
+				"hope", "hoped", "hopes", "hoping",
+				"play", "played", "playing", "plays",
+				"redo", "replay", "replayed", "replaying", "replays",
+				"tie",
+				"undo", "untie",
+			},
+			rejected: []RejectedLine{
+				{Line: 2, Text: "a", Err: ErrInvalidKey},
+				{Line: 9, Text: "hopes", Err: ErrDuplicateKey},
+			},
+		},
+		{
+			name:     "latin-1 sample",
+			dic:      "testdata/latin1.dic",
+			aff:      "testdata/latin1.aff",
+			expected: []string{"café", "cafés", "naïve", "naïvement", "été"},
+		},
+	}
+
+	for _, test := range hunspellTests {
+		dic, err := os.Open(test.dic)
+		if err != nil {
+			t.Fatalf("test '%s': %v", test.name, err)
+		}
+		aff, err := os.Open(test.aff)
+		if err != nil {
+			t.Fatalf("test '%s': %v", test.name, err)
+		}
+
+		trie := NewTrie()
+		report, err := trie.LoadHunspell(dic, aff)
+		dic.Close()
+		aff.Close()
+		if err != nil {
+			t.Errorf("test '%s': unexpected error: %v", test.name, err)
+			continue
+		}
+		checkValid(t, test.name, &trie)
+
+		entries := trie.Entries()
+		slices.Sort(entries)
+		if !reflect.DeepEqual(entries, test.expected) {
+			t.Errorf("test '%s': expected entries %v, but were %v", test.name, test.expected, entries)
+		}
+		if report.Loaded != len(test.expected) {
+			t.Errorf("test '%s': expected %d loaded, but was %d", test.name, len(test.expected), report.Loaded)
+		}
+		if len(report.Rejected) != len(test.rejected) {
+			t.Errorf("test '%s': expected rejections %v, but were %v", test.name, test.rejected, report.Rejected)
+			continue
+		}
+		for i, r := range report.Rejected {
+			expected := test.rejected[i]
+			if r.Line != expected.Line || r.Text != expected.Text || !errors.Is(r.Err, expected.Err) {
+				t.Errorf("test '%s': expected rejection %v, but was %v", test.name, expected, r)
+			}
+		}
+	}
+}
+
+func TestLoadHunspellFlags(t *testing.T) {
+
+	flagTests := []struct {
+		name     string
+		dic      string
+		aff      string
+		expected []string
+	}{
+		{
+			name:     "long flags",
+			dic:      "1\nwalk/AaBb\n",
+			aff:      "FLAG long\nSFX Aa Y 1\nSFX Aa 0 ed .\nSFX Bb Y 1\nSFX Bb 0 ing .\n",
+			expected: []string{"walk", "walked", "walking"},
+		},
+		{
+			name:     "numeric flags",
+			dic:      "1\nwalk/12,345\n",
+			aff:      "FLAG num\nSFX 12 Y 1\nSFX 12 0 ed .\nSFX 345 Y 1\nSFX 345 0 s .\n",
+			expected: []string{"walk", "walked", "walks"},
+		},
+		{
+			name:     "UTF-8 flags and continuation classes",
+			dic:      "1\nwalk/é\n",
+			aff:      "FLAG UTF-8\nSFX é Y 1\nSFX é 0 er/X .\n",
+			expected: []string{"walk", "walker"},
+		},
+		{
+			name:     "cross product with stripping",
+			dic:      "1\nhappy/UY\n",
+			aff:      "PFX U Y 1\nPFX U 0 un .\nSFX Y Y 1\nSFX Y y ily y\n",
+			expected: []string{"happily", "happy", "unhappily", "unhappy"},
+		},
+		{
+			name:     "no cross product",
+			dic:      "1\nhappy/UY\n",
+			aff:      "PFX U N 1\nPFX U 0 un .\nSFX Y Y 1\nSFX Y y ily y\n",
+			expected: []string{"happily", "happy", "unhappy"},
+		},
+		{
+			name:     "escaped slash",
+			dic:      "1\nand\\/or\n",
+			aff:      "",
+			expected: []string{"and/or"},
+		},
+	}
+
+	for _, test := range flagTests {
+		trie := NewTrie()
+		if _, err := trie.LoadHunspell(strings.NewReader(test.dic), strings.NewReader(test.aff)); err != nil {
+			t.Errorf("test '%s': unexpected error: %v", test.name, err)
+			continue
+		}
+		checkValid(t, test.name, &trie)
+		entries := trie.Entries()
+		slices.Sort(entries)
+		if !reflect.DeepEqual(entries, test.expected) {
+			t.Errorf("test '%s': expected entries %v, but were %v", test.name, test.expected, entries)
+		}
+	}
+}
+
+func TestLoadHunspellErrors(t *testing.T) {
+
+	errorTests := []struct {
+		name string
+		aff  string
+	}{
+		{
+			name: "unsupported encoding",
+			aff:  "SET KOI8-R\n",
+		},
+		{
+			name: "truncated rule",
+			aff:  "SFX S Y 1\nSFX S 0\n",
+		},
+		{
+			name: "unterminated condition",
+			aff:  "SFX S Y 1\nSFX S 0 s [abc\n",
+		},
+	}
+
+	for _, test := range errorTests {
+		trie := NewTrie()
+		if _, err := trie.LoadHunspell(strings.NewReader("1\nwalk/S\n"), strings.NewReader(test.aff)); err == nil {
+			t.Errorf("test '%s': expected an error", test.name)
+		}
+	}
+}
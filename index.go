@@ -0,0 +1,178 @@
+package trie
+
+import (
+	"fmt"
+	"slices"
+	"unicode/utf8"
+)
+
+// The kinds of child index, which adapt to the number of children
+// (and the runes which begin them) much as the node kinds of an
+// Adaptive Radix Tree do:
+//
+//   - up to maxScanChildren children have no index at all, and are
+//     scanned (so that leaves and small nodes carry no extra memory)
+//   - up to maxSortedChildren children have their first runes kept
+//     in a sorted array, which is binary searched
+//   - more children, all beginning with runes below 256 (as they
+//     would in byte mode), are found from a 256-entry table
+//   - more children, with any larger runes (as in CJK text), are
+//     found from a map of their first runes
+//
+// The children themselves are never reordered, so Walk still visits
+// them in the order in which they were created.
+const (
+	maxScanChildren   = 8
+	maxSortedChildren = 48
+)
+
+// childIndex locates children by the first rune of their values.
+// It covers the first n children; if there are more (or fewer)
+// then it is out of date and the children are scanned instead, so
+// nodes built directly (as in tests) still work, just more slowly.
+// A nil index is always scanned.
+type childIndex struct {
+	n      int
+	sorted []rune       // first runes, in order
+	at     []uint8      // the position of each of sorted
+	bytes  *[256]uint16 // position + 1, so zero means no child
+	runes  map[rune]int
+}
+
+// indexChildren returns a new index for children, of the smallest
+// kind which will hold them (or nil, if they are few enough to be
+// scanned). Where first runes are repeated (which is invalid) the
+// first child with the rune is kept.
+func indexChildren(children []*Node) *childIndex {
+
+	if len(children) <= maxScanChildren {
+		return nil
+	}
+
+	x := &childIndex{n: len(children)}
+	if len(children) <= maxSortedChildren {
+		for i, c := range children {
+			x.insertSorted(c, i)
+		}
+		return x
+	}
+
+	wide := false
+	for _, c := range children {
+		if r, _ := utf8.DecodeRuneInString(c.value); r >= 256 {
+			wide = true
+			break
+		}
+	}
+	if wide {
+		x.runes = make(map[rune]int, len(children))
+	} else {
+		x.bytes = new([256]uint16)
+	}
+	for i := len(children) - 1; i >= 0; i-- {
+		r, _ := utf8.DecodeRuneInString(children[i].value)
+		if wide {
+			x.runes[r] = i
+		} else {
+			x.bytes[r] = uint16(i + 1)
+		}
+	}
+	return x
+}
+
+// insertSorted adds c, at position i, to the sorted array.
+func (x *childIndex) insertSorted(c *Node, i int) {
+
+	r, _ := utf8.DecodeRuneInString(c.value)
+	j, found := slices.BinarySearch(x.sorted, r)
+	if found {
+		return
+	}
+	x.sorted = slices.Insert(x.sorted, j, r)
+	x.at = slices.Insert(x.at, j, uint8(i))
+}
+
+// find returns the position of the child beginning with r, or -1.
+func (x *childIndex) find(children []*Node, r rune) int {
+
+	if x == nil || x.n != len(children) {
+		for i, c := range children {
+			if first, _ := utf8.DecodeRuneInString(c.value); first == r && c.value != "" {
+				return i
+			}
+		}
+		return -1
+	}
+
+	switch {
+	case x.runes != nil:
+		if i, ok := x.runes[r]; ok {
+			return i
+		}
+	case x.bytes != nil:
+		if r >= 0 && r < 256 {
+			return int(x.bytes[r]) - 1
+		}
+	default:
+		if j, found := slices.BinarySearch(x.sorted, r); found {
+			return int(x.at[j])
+		}
+	}
+	return -1
+}
+
+// added returns the index updated for a child which has just been
+// appended to children, which may be a new index of a bigger kind.
+func (x *childIndex) added(children []*Node) *childIndex {
+
+	if x == nil || x.n != len(children)-1 {
+		return indexChildren(children)
+	}
+
+	i := len(children) - 1
+	r, _ := utf8.DecodeRuneInString(children[i].value)
+	switch {
+	case x.runes != nil:
+		x.runes[r] = i
+	case x.bytes != nil && r < 256:
+		x.bytes[r] = uint16(i + 1)
+	case x.bytes == nil && len(children) <= maxSortedChildren:
+		x.insertSorted(children[i], i)
+	default:
+		// Time to grow into a bigger kind
+		return indexChildren(children)
+	}
+	x.n = len(children)
+	return x
+}
+
+// check reports whether the index (if it is up to date) agrees with
+// children.
+func (x *childIndex) check(children []*Node) error {
+
+	if x == nil || x.n != len(children) {
+		// Missing or out of date indexes are not used
+		return nil
+	}
+	for i, c := range children {
+		r, _ := utf8.DecodeRuneInString(c.value)
+		if x.find(children, r) != i {
+			return fmt.Errorf("child %q is not indexed", c.value)
+		}
+	}
+	entries := len(x.sorted) + len(x.runes)
+	if x.bytes != nil {
+		for _, p := range x.bytes {
+			if p != 0 {
+				entries++
+			}
+		}
+	}
+	if entries != len(children) {
+		return fmt.Errorf("%d children but %d index entries", len(children), entries)
+	}
+	if !slices.IsSorted(x.sorted) {
+		return fmt.Errorf("first runes %q are not sorted", x.sorted)
+	}
+	return nil
+}
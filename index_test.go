@@ -0,0 +1,234 @@
+package trie
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChildIndexKinds(t *testing.T) {
+
+	kindTests := []struct {
+		name     string
+		firsts   string
+		expected string
+	}{
+		{
+			name:     "no children",
+			firsts:   "",
+			expected: "none",
+		},
+		{
+			name:     "few children",
+			firsts:   "zyxwvuts",
+			expected: "none",
+		},
+		{
+			name:     "some children",
+			firsts:   "zyxwvutsrqponm大lkjihgfedcba",
+			expected: "sorted",
+		},
+		{
+			name:     "many byte-sized children",
+			firsts:   "abcdefghijklmnopqrstuvwxyzàéABCDEFGHIJKLMNOPQRSTUVWXYZ",
+			expected: "bytes",
+		},
+		{
+			name:     "many runes",
+			firsts:   "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ大",
+			expected: "runes",
+		},
+		{
+			name:     "many runes, first added while sorted",
+			firsts:   "大abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+			expected: "runes",
+		},
+	}
+
+	for _, test := range kindTests {
+		n := makeNode("x", false)
+		for _, r := range test.firsts {
+			n.makeChildNode(string(r)+"yz", true)
+		}
+
+		kind := "none"
+		switch {
+		case n.index == nil:
+		case n.index.bytes != nil:
+			kind = "bytes"
+		case n.index.runes != nil:
+			kind = "runes"
+		default:
+			kind = "sorted"
+		}
+		if kind != test.expected {
+			t.Errorf("test '%s': expected the %s kind, but was %s", test.name, test.expected, kind)
+		}
+		if err := n.index.check(n.children); err != nil {
+			t.Errorf("test '%s': %v", test.name, err)
+		}
+
+		i := 0
+		for _, r := range test.firsts {
+			if p, c := n.child(string(r)); p != i || c != n.children[i] {
+				t.Errorf("test '%s': expected '%c' at position %d, but was %d", test.name, r, i, p)
+			}
+			i++
+		}
+		if p, c := n.child("?"); p != -1 || c != nil {
+			t.Errorf("test '%s': expected no child for '?'", test.name)
+		}
+	}
+}
+
+func TestStaleChildIndex(t *testing.T) {
+
+	// Nodes built directly have no index, so are scanned
+	n := Node{value: "x", childCount: maxScanChildren}
+	for i := range maxScanChildren {
+		n.children = append(n.children, &Node{value: string(rune('a'+i)) + "z", entry: true})
+	}
+	if p, _ := n.child("bz"); p != 1 {
+		t.Errorf("expected 'bz' at position 1, but was %d", p)
+	}
+
+	// Adding to them builds the index (once there are enough)
+	n.makeChildNode("zz", true)
+	if n.index == nil || n.index.n != maxScanChildren+1 {
+		t.Fatalf("expected the index to cover %d children", maxScanChildren+1)
+	}
+	if err := n.index.check(n.children); err != nil {
+		t.Error(err)
+	}
+}
+
+// wideKeys returns keys with thousands of CJK root runes, beneath
+// each of which are dozens of Latin and CJK second runes.
+func wideKeys() []string {
+
+	var keys []string
+	for r := rune(0x4e00); r < 0x4e00+2000; r++ {
+		for _, second := range "abcdefghijklmnopqrstuvwxyz豆油米面" {
+			keys = append(keys, string([]rune{r, second, 'z'}))
+		}
+	}
+	return keys
+}
+
+func TestWideFanout(t *testing.T) {
+
+	keys := wideKeys()
+	trie := NewTrie()
+	for _, s := range keys {
+		if !trie.Insert(s) {
+			t.Fatalf("expected to insert '%s'", s)
+		}
+	}
+	checkValid(t, "wide", &trie)
+
+	// Delete every other key, which shrinks the indexes
+	for i := 0; i < len(keys); i += 2 {
+		if !trie.Delete(keys[i]) {
+			t.Fatalf("expected to delete '%s'", keys[i])
+		}
+	}
+	checkValid(t, "wide after delete", &trie)
+
+	for i, s := range keys {
+		if found, _ := trie.Find(s); found != (i%2 == 1) {
+			t.Errorf("'%s': expected found to be %t", s, i%2 == 1)
+		}
+	}
+	if longest, _ := trie.LongestPrefix(keys[1] + "zz"); longest != keys[1] {
+		t.Errorf("expected longest prefix '%s', but was '%s'", keys[1], longest)
+	}
+	if entries := trie.WithPrefix(keys[1][:len(keys[1])-1]); len(entries) != 1 {
+		t.Errorf("expected one entry with prefix, but was %v", entries)
+	}
+}
+
+// findScan looks up s by scanning every child (as Find did before
+// children were indexed), to compare with in benchmarks.
+func findScan(t *Trie, s string) *Node {
+
+	_, size := utf8.DecodeRuneInString(s)
+	children := t.child
+	for _, c := range children {
+		if c.value == s[:size] {
+			s = s[size:]
+			children = c.children
+			for s != "" {
+				var next *Node
+				for _, c := range children {
+					if strings.HasPrefix(s, c.value) {
+						next = c
+						break
+					}
+				}
+				if next == nil {
+					return nil
+				}
+				s = s[len(next.value):]
+				if s == "" {
+					return next
+				}
+				children = next.children
+			}
+		}
+	}
+	return nil
+}
+
+func BenchmarkFindWide(b *testing.B) {
+
+	keys := wideKeys()
+	trie := NewTrie()
+	for _, s := range keys {
+		trie.Insert(s)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		benchmarkFound, benchmarkN = trie.Find(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkFindWideScan(b *testing.B) {
+
+	keys := wideKeys()
+	trie := NewTrie()
+	for _, s := range keys {
+		trie.Insert(s)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		benchmarkN = findScan(&trie, keys[i%len(keys)])
+	}
+}
+
+func BenchmarkFindNarrow(b *testing.B) {
+
+	trie := NewTrie()
+	for _, s := range benchmarkKeys {
+		trie.Insert(s)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		benchmarkFound, benchmarkN = trie.Find(benchmarkKeys[i%len(benchmarkKeys)])
+	}
+}
+
+func BenchmarkFindNarrowScan(b *testing.B) {
+
+	trie := NewTrie()
+	for _, s := range benchmarkKeys {
+		trie.Insert(s)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		benchmarkN = findScan(&trie, benchmarkKeys[i%len(benchmarkKeys)])
+	}
+}
@@ -0,0 +1,306 @@
+package trie
+
+import (
+	"iter"
+	"net/netip"
+)
+
+// IPTable maps IP prefixes (in CIDR notation, such as 10.0.0.0/8 or
+// 2001:db8::/32) to values, for routing tables or allow-lists. It is
+// a binary radix trie, like Patricia, except that its keys are
+// prefixes of varying length rather than fixed-width keys: each node
+// is a prefix, which only has the nodes of longer prefixes within it
+// below it, and single-child nodes are only kept for prefixes in the
+// table. IPv4 and IPv6 prefixes are kept in separate tries, so an
+// IPv4 address never matches an IPv6 prefix (including IPv4-mapped
+// ones) nor vice versa.
+type IPTable[V any] struct {
+	v4    *ipNode[V]
+	v6    *ipNode[V]
+	count int
+}
+
+// ipNode is a prefix of length bits (the rest of key being zero),
+// which is only in the table if set. An IPv4 key is in its first 4
+// bytes.
+type ipNode[V any] struct {
+	key   [16]byte
+	bits  int
+	set   bool
+	value V
+	child [2]*ipNode[V]
+}
+
+// NewIPTable returns an empty IPTable.
+func NewIPTable[V any]() *IPTable[V] {
+	return &IPTable[V]{}
+}
+
+// ipKey returns the key of the masked prefix p, and the root of the
+// trie it belongs in.
+func (t *IPTable[V]) ipKey(p netip.Prefix) ([16]byte, **ipNode[V]) {
+
+	var key [16]byte
+	if p.Addr().Is4() {
+		a := p.Addr().As4()
+		copy(key[:], a[:])
+		return key, &t.v4
+	}
+	return p.Addr().As16(), &t.v6
+}
+
+// ipCommon returns the length of the prefix which a and b (of lengths
+// aBits & bBits) have in common.
+func ipCommon(a [16]byte, aBits int, b [16]byte, bBits int) int {
+
+	common := min(aBits, bBits)
+	if d := patriciaDiff(a, b); d >= 0 {
+		common = min(common, d)
+	}
+	return common
+}
+
+// Count returns the number of prefixes in the table.
+func (t *IPTable[V]) Count() int {
+	return t.count
+}
+
+// Insert sets the value for the prefix p (its host bits are ignored),
+// returning false if p is invalid or was already in the table (in
+// which case its value is replaced).
+func (t *IPTable[V]) Insert(p netip.Prefix, v V) bool {
+
+	if !p.IsValid() {
+		return false
+	}
+	p = p.Masked()
+	key, link := t.ipKey(p)
+	bits := p.Bits()
+
+	for {
+		n := *link
+		if n == nil {
+			*link = &ipNode[V]{key: key, bits: bits, set: true, value: v}
+			t.count++
+			return true
+		}
+
+		common := ipCommon(key, bits, n.key, n.bits)
+		switch {
+		case common == n.bits && common == bits:
+			// This is the prefix
+			inserted := !n.set
+			n.set, n.value = true, v
+			if inserted {
+				t.count++
+			}
+			return inserted
+
+		case common == n.bits:
+			// The prefix is within n
+			link = &n.child[patriciaBit(key, n.bits)]
+			continue
+
+		case common == bits:
+			// n is within the prefix
+			added := &ipNode[V]{key: key, bits: bits, set: true, value: v}
+			added.child[patriciaBit(n.key, bits)] = n
+			*link = added
+
+		default:
+			// They part at common, so need a node for what they share
+			parent := &ipNode[V]{key: ipMask(key, common), bits: common}
+			added := &ipNode[V]{key: key, bits: bits, set: true, value: v}
+			parent.child[patriciaBit(key, common)] = added
+			parent.child[patriciaBit(n.key, common)] = n
+			*link = parent
+		}
+		t.count++
+		return true
+	}
+}
+
+// ipMask returns key with all but its first bits bits cleared.
+func ipMask(key [16]byte, bits int) [16]byte {
+
+	for i := range key {
+		switch {
+		case bits >= 8*(i+1):
+		case bits <= 8*i:
+			key[i] = 0
+		default:
+			key[i] &^= 0xff >> (bits - 8*i)
+		}
+	}
+	return key
+}
+
+// Get returns the value for exactly the prefix p (its host bits are
+// ignored), if it is in the table.
+func (t *IPTable[V]) Get(p netip.Prefix) (V, bool) {
+
+	var zero V
+	if !p.IsValid() {
+		return zero, false
+	}
+	p = p.Masked()
+	key, link := t.ipKey(p)
+	for n := *link; n != nil && n.bits <= p.Bits(); n = n.child[patriciaBit(key, n.bits)] {
+		if ipCommon(key, p.Bits(), n.key, n.bits) < n.bits {
+			break
+		}
+		if n.bits == p.Bits() {
+			return n.value, n.set
+		}
+	}
+	return zero, false
+}
+
+// Lookup returns the longest prefix in the table which contains addr,
+// with its value, if there is one.
+func (t *IPTable[V]) Lookup(addr netip.Addr) (netip.Prefix, V, bool) {
+
+	var zero V
+	if !addr.IsValid() {
+		return netip.Prefix{}, zero, false
+	}
+	addr = addr.WithZone("")
+	found, value, ok := netip.Prefix{}, zero, false
+	for p, v := range t.Covering(netip.PrefixFrom(addr, addr.BitLen())) {
+		found, value, ok = p, v, true
+	}
+	return found, value, ok
+}
+
+// Delete removes the prefix p (its host bits are ignored) from the
+// table, returning false if it was not there.
+func (t *IPTable[V]) Delete(p netip.Prefix) bool {
+
+	if !p.IsValid() {
+		return false
+	}
+	p = p.Masked()
+	key, root := t.ipKey(p)
+
+	// The nodes on the way down, so that any left with fewer than two
+	// children and no prefix of their own can be removed on the way up
+	links := []**ipNode[V]{root}
+	for {
+		n := *links[len(links)-1]
+		if n == nil || n.bits > p.Bits() || ipCommon(key, p.Bits(), n.key, n.bits) < n.bits {
+			return false
+		}
+		if n.bits == p.Bits() {
+			break
+		}
+		links = append(links, &n.child[patriciaBit(key, n.bits)])
+	}
+
+	n := *links[len(links)-1]
+	if !n.set {
+		return false
+	}
+	var zero V
+	n.set, n.value = false, zero
+	t.count--
+
+	for i := len(links) - 1; i >= 0; i-- {
+		n := *links[i]
+		if n.set || (n.child[0] != nil && n.child[1] != nil) {
+			break
+		}
+		if n.child[0] != nil {
+			*links[i] = n.child[0]
+		} else {
+			*links[i] = n.child[1]
+		}
+	}
+	return true
+}
+
+// prefix returns the node's prefix.
+func (n *ipNode[V]) prefix(is4 bool) netip.Prefix {
+
+	if is4 {
+		return netip.PrefixFrom(netip.AddrFrom4([4]byte(n.key[:4])), n.bits)
+	}
+	return netip.PrefixFrom(netip.AddrFrom16(n.key), n.bits)
+}
+
+// Covering returns every prefix in the table which contains p
+// (including p itself), with its value, from the shortest to the
+// longest.
+func (t *IPTable[V]) Covering(p netip.Prefix) iter.Seq2[netip.Prefix, V] {
+
+	return func(yield func(netip.Prefix, V) bool) {
+		if !p.IsValid() {
+			return
+		}
+		p = p.Masked()
+		key, link := t.ipKey(p)
+		for n := *link; n != nil && n.bits <= p.Bits(); n = n.child[patriciaBit(key, n.bits)] {
+			if ipCommon(key, p.Bits(), n.key, n.bits) < n.bits {
+				return
+			}
+			if n.set && !yield(n.prefix(p.Addr().Is4()), n.value) {
+				return
+			}
+			if n.bits == p.Addr().BitLen() {
+				return
+			}
+		}
+	}
+}
+
+// Covered returns every prefix in the table which is within p
+// (including p itself), with its value, in order of address (and,
+// for the same address, from the shortest to the longest).
+func (t *IPTable[V]) Covered(p netip.Prefix) iter.Seq2[netip.Prefix, V] {
+
+	return func(yield func(netip.Prefix, V) bool) {
+		if !p.IsValid() {
+			return
+		}
+		p = p.Masked()
+		key, link := t.ipKey(p)
+
+		// Find the shortest prefix within p
+		n := *link
+		for n != nil && n.bits < p.Bits() {
+			if ipCommon(key, p.Bits(), n.key, n.bits) < n.bits {
+				return
+			}
+			n = n.child[patriciaBit(key, n.bits)]
+		}
+		if n != nil && ipCommon(key, p.Bits(), n.key, n.bits) == p.Bits() {
+			n.visit(p.Addr().Is4(), yield)
+		}
+	}
+}
+
+// All returns every prefix in the table with its value, the IPv4
+// prefixes first, each in order as for Covered.
+func (t *IPTable[V]) All() iter.Seq2[netip.Prefix, V] {
+
+	return func(yield func(netip.Prefix, V) bool) {
+		if t.v4 != nil && !t.v4.visit(true, yield) {
+			return
+		}
+		if t.v6 != nil {
+			t.v6.visit(false, yield)
+		}
+	}
+}
+
+func (n *ipNode[V]) visit(is4 bool, yield func(netip.Prefix, V) bool) bool {
+
+	if n.set && !yield(n.prefix(is4), n.value) {
+		return false
+	}
+	for _, c := range n.child {
+		if c != nil && !c.visit(is4, yield) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,267 @@
+package trie
+
+import (
+	"math/rand"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+// checkIPTable checks that every node is within its parent, on the
+// side given by its first bit beyond the parent, and that every node
+// which is not in the table has two children.
+func checkIPTable[V any](t *testing.T, name string, table *IPTable[V]) {
+
+	t.Helper()
+	count := 0
+	var visit func(n *ipNode[V])
+	visit = func(n *ipNode[V]) {
+		if n.set {
+			count++
+		} else if n.child[0] == nil || n.child[1] == nil {
+			t.Errorf("test '%s': node /%d is not in the table, but has fewer than two children", name, n.bits)
+		}
+		if ipMask(n.key, n.bits) != n.key {
+			t.Errorf("test '%s': node /%d has host bits set", name, n.bits)
+		}
+		for b, c := range n.child {
+			if c == nil {
+				continue
+			}
+			if c.bits <= n.bits || ipCommon(n.key, n.bits, c.key, c.bits) != n.bits || patriciaBit(c.key, n.bits) != b {
+				t.Errorf("test '%s': node /%d is misplaced below /%d", name, c.bits, n.bits)
+			}
+			visit(c)
+		}
+	}
+	for _, root := range []*ipNode[V]{table.v4, table.v6} {
+		if root != nil {
+			visit(root)
+		}
+	}
+	if count != table.Count() {
+		t.Errorf("test '%s': expected %d prefixes, but there were %d", name, table.Count(), count)
+	}
+}
+
+func getIPTable() *IPTable[string] {
+
+	table := NewIPTable[string]()
+	for _, s := range []string{
+		"0.0.0.0/0",
+		"10.0.0.0/8",
+		"10.1.0.0/16",
+		"10.1.2.0/24",
+		"10.2.0.0/16",
+		"192.168.1.0/24",
+		"192.168.1.7/32",
+		"2001:db8::/32",
+		"2001:db8:1::/48",
+		"2001:db8:1::1/128",
+		"::ffff:10.0.0.0/104",
+	} {
+		table.Insert(netip.MustParsePrefix(s), s)
+	}
+	return table
+}
+
+func TestIPTableLookup(t *testing.T) {
+
+	table := getIPTable()
+	checkIPTable(t, "lookup", table)
+
+	lookupTests := []struct {
+		addr     string
+		expected string
+	}{
+		{addr: "10.1.2.3", expected: "10.1.2.0/24"},
+		{addr: "10.1.3.3", expected: "10.1.0.0/16"},
+		{addr: "10.3.0.1", expected: "10.0.0.0/8"},
+		{addr: "10.2.255.255", expected: "10.2.0.0/16"},
+		{addr: "192.168.1.7", expected: "192.168.1.7/32"},
+		{addr: "192.168.1.8", expected: "192.168.1.0/24"},
+		{addr: "8.8.8.8", expected: "0.0.0.0/0"},
+		{addr: "2001:db8:1::1", expected: "2001:db8:1::1/128"},
+		{addr: "2001:db8:1::2", expected: "2001:db8:1::/48"},
+		{addr: "2001:db8:2::1%eth0", expected: "2001:db8::/32"},
+		{addr: "::ffff:10.1.2.3", expected: "::ffff:10.0.0.0/104"},
+		{addr: "2001:db9::1", expected: ""},
+	}
+
+	for _, test := range lookupTests {
+		prefix, value, ok := table.Lookup(netip.MustParseAddr(test.addr))
+		if test.expected == "" {
+			if ok {
+				t.Errorf("lookup %s: expected no match, but was %s", test.addr, prefix)
+			}
+			continue
+		}
+		if !ok || prefix.String() != test.expected || value != test.expected {
+			t.Errorf("lookup %s: expected %s, but was (%s, %q, %t)", test.addr, test.expected, prefix, value, ok)
+		}
+	}
+	if _, _, ok := table.Lookup(netip.Addr{}); ok {
+		t.Errorf("expected the zero address not to match")
+	}
+}
+
+func TestIPTableInsertDelete(t *testing.T) {
+
+	table := getIPTable()
+
+	insertTests := []struct {
+		prefix   netip.Prefix
+		inserted bool
+	}{
+		{prefix: netip.MustParsePrefix("10.1.0.0/16"), inserted: false},
+		{prefix: netip.MustParsePrefix("10.1.9.9/16"), inserted: false},
+		{prefix: netip.MustParsePrefix("10.0.0.0/9"), inserted: true},
+		{prefix: netip.MustParsePrefix("2001:db8::/31"), inserted: true},
+		{prefix: netip.Prefix{}, inserted: false},
+	}
+
+	for _, test := range insertTests {
+		if inserted := table.Insert(test.prefix, "new"); inserted != test.inserted {
+			t.Errorf("insert %s: expected inserted to be %t", test.prefix, test.inserted)
+		}
+		checkIPTable(t, "insert", table)
+	}
+	if v, ok := table.Get(netip.MustParsePrefix("10.1.0.0/16")); !ok || v != "new" {
+		t.Errorf("expected the value of 10.1.0.0/16 to be replaced, but was (%q, %t)", v, ok)
+	}
+	if _, ok := table.Get(netip.MustParsePrefix("10.1.0.0/17")); ok {
+		t.Errorf("expected not to get 10.1.0.0/17")
+	}
+
+	deleteTests := []struct {
+		prefix  string
+		deleted bool
+	}{
+		{prefix: "10.1.0.0/16", deleted: true},
+		{prefix: "10.1.0.0/16", deleted: false},
+		{prefix: "10.1.2.0/23", deleted: false},
+		{prefix: "0.0.0.0/0", deleted: true},
+		{prefix: "10.1.2.0/24", deleted: true},
+		{prefix: "2001:db8::/32", deleted: true},
+		{prefix: "2001:db8:1::1/128", deleted: true},
+		{prefix: "::/0", deleted: false},
+	}
+
+	for _, test := range deleteTests {
+		if deleted := table.Delete(netip.MustParsePrefix(test.prefix)); deleted != test.deleted {
+			t.Errorf("delete %s: expected deleted to be %t", test.prefix, test.deleted)
+		}
+		checkIPTable(t, "delete", table)
+	}
+
+	prefix, _, _ := table.Lookup(netip.MustParseAddr("10.1.2.3"))
+	if prefix.String() != "10.0.0.0/9" {
+		t.Errorf("expected 10.1.2.3 to match 10.0.0.0/9, but was %s", prefix)
+	}
+	if _, _, ok := table.Lookup(netip.MustParseAddr("8.8.8.8")); ok {
+		t.Errorf("expected 8.8.8.8 not to match")
+	}
+}
+
+func TestIPTableCovered(t *testing.T) {
+
+	table := getIPTable()
+
+	coverTests := []struct {
+		prefix   string
+		covering []string
+		covered  []string
+	}{
+		{
+			prefix:   "10.1.0.0/16",
+			covering: []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16"},
+			covered:  []string{"10.1.0.0/16", "10.1.2.0/24"},
+		},
+		{
+			prefix:   "10.0.0.0/7",
+			covering: []string{"0.0.0.0/0"},
+			covered:  []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.2.0.0/16"},
+		},
+		{
+			prefix:   "192.168.0.0/16",
+			covering: []string{"0.0.0.0/0"},
+			covered:  []string{"192.168.1.0/24", "192.168.1.7/32"},
+		},
+		{
+			prefix:   "10.1.2.128/25",
+			covering: []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24"},
+			covered:  nil,
+		},
+		{
+			prefix:   "2001:db8::/16",
+			covering: nil,
+			covered:  []string{"2001:db8::/32", "2001:db8:1::/48", "2001:db8:1::1/128"},
+		},
+		{
+			prefix:   "::/0",
+			covering: nil,
+			covered:  []string{"::ffff:10.0.0.0/104", "2001:db8::/32", "2001:db8:1::/48", "2001:db8:1::1/128"},
+		},
+	}
+
+	collect := func(seq func(func(netip.Prefix, string) bool)) []string {
+		var found []string
+		for p, v := range seq {
+			if p.String() != v {
+				t.Errorf("expected the value of %s to be %q, but was %q", p, p, v)
+			}
+			found = append(found, p.String())
+		}
+		return found
+	}
+
+	for _, test := range coverTests {
+		p := netip.MustParsePrefix(test.prefix)
+		if found := collect(table.Covering(p)); !reflect.DeepEqual(found, test.covering) {
+			t.Errorf("covering %s: expected %v, but was %v", test.prefix, test.covering, found)
+		}
+		if found := collect(table.Covered(p)); !reflect.DeepEqual(found, test.covered) {
+			t.Errorf("covered %s: expected %v, but was %v", test.prefix, test.covered, found)
+		}
+	}
+
+	if found := collect(table.All()); len(found) != table.Count() || found[0] != "0.0.0.0/0" {
+		t.Errorf("expected all %d prefixes, IPv4 first, but was %v", table.Count(), found)
+	}
+}
+
+func TestIPTableRandom(t *testing.T) {
+
+	// Compare Lookup with a scan of every prefix
+	r := rand.New(rand.NewSource(1))
+	table := NewIPTable[int]()
+	var prefixes []netip.Prefix
+	for i := 0; i < 500; i++ {
+		a := netip.AddrFrom4([4]byte{10, byte(r.Intn(4)), byte(r.Intn(256)), byte(r.Intn(256))})
+		p, _ := a.Prefix(8 + r.Intn(25))
+		if table.Insert(p, i) {
+			prefixes = append(prefixes, p)
+		}
+		if i%5 == 0 {
+			j := r.Intn(len(prefixes))
+			if !table.Delete(prefixes[j]) {
+				t.Fatalf("expected to delete %s", prefixes[j])
+			}
+			prefixes = append(prefixes[:j], prefixes[j+1:]...)
+		}
+	}
+	checkIPTable(t, "random", table)
+
+	for i := 0; i < 1000; i++ {
+		a := netip.AddrFrom4([4]byte{10, byte(r.Intn(4)), byte(r.Intn(256)), byte(r.Intn(256))})
+		var longest netip.Prefix
+		for _, p := range prefixes {
+			if p.Contains(a) && (!longest.IsValid() || p.Bits() > longest.Bits()) {
+				longest = p
+			}
+		}
+		if found, _, _ := table.Lookup(a); found != longest {
+			t.Fatalf("lookup %s: expected %s, but was %s", a, longest, found)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package trie
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// jsonNode is the nested JSON representation of a node. The
+// trie itself is represented as a node with an empty edge,
+// whose children are the root rune nodes (and which alone may
+// be marked as folded).
+type jsonNode struct {
+	Edge     string      `json:"edge"`
+	Entry    bool        `json:"entry"`
+	Data     string      `json:"data,omitempty"`
+	Weight   int         `json:"weight,omitempty"`
+	Keys     []string    `json:"keys,omitempty"`
+	Folded   bool        `json:"folded,omitempty"`
+	Children []*jsonNode `json:"children,omitempty"`
+}
+
+// MarshalJSON encodes the trie as nested JSON objects of the
+// form {"edge": ..., "entry": ..., "children": [...]}. Data and
+// weights are included (as "data" and "weight") for those entries
+// which have any. A case-insensitive trie is marked as "folded" at
+// the root, with the original terms of each entry as its "keys".
+// To encode just the entries use JSONKeys instead.
+func (t *Trie) MarshalJSON() ([]byte, error) {
+
+	t = t.tree()
+	root := jsonNode{Folded: t.fold}
+	for _, c := range t.child {
+		root.Children = append(root.Children, toJSONNode(c))
+	}
+	return json.Marshal(root)
+}
+
+// UnmarshalJSON replaces the contents of the trie with those
+// decoded from the nested form written by MarshalJSON.
+func (t *Trie) UnmarshalJSON(b []byte) error {
+
+	var root jsonNode
+	if err := json.Unmarshal(b, &root); err != nil {
+		return err
+	}
+	if root.Edge != "" || root.Entry {
+		return errors.New("trie: JSON root must have an empty edge and no entry")
+	}
+
+	decoded := NewTrie()
+	decoded.fold = root.Folded
+	for _, c := range root.Children {
+		n, err := fromJSONNode(c, &decoded.count)
+		if err != nil {
+			return err
+		}
+		decoded.child = append(decoded.child, n)
+	}
+	decoded.roots = indexChildren(decoded.child)
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	t.replace(decoded)
+	return nil
+}
+
+func toJSONNode(n *Node) *jsonNode {
+
+	j := &jsonNode{Edge: n.value, Entry: n.entry, Data: n.data, Weight: n.weight, Keys: n.keys}
+	for _, c := range n.children {
+		j.Children = append(j.Children, toJSONNode(c))
+	}
+	return j
+}
+
+func fromJSONNode(j *jsonNode, count *int) (*Node, error) {
+
+	if j == nil || j.Edge == "" {
+		return nil, errors.New("trie: JSON node must have a non-empty edge")
+	}
+	if j.Folded {
+		return nil, errors.New("trie: only the JSON root may be folded")
+	}
+	n := makeNode(j.Edge, j.Entry)
+	n.data = j.Data
+	n.weight = j.Weight
+	n.keys = j.Keys
+	if n.entry {
+		*count++
+	}
+	for _, c := range j.Children {
+		child, err := fromJSONNode(c, count)
+		if err != nil {
+			return nil, err
+		}
+		n.children = append(n.children, child)
+	}
+	n.childCount = len(n.children)
+	n.index = indexChildren(n.children)
+	return &n, nil
+}
+
+// JSONKeys wraps a trie so that it is encoded to JSON as a
+// flat list of its entries (in the order given by Walk) rather
+// than as nested nodes. Decoding inserts each listed entry into
+// a new trie (case-insensitive or arena-backed, if the wrapped
+// one is), which then replaces the wrapped one. Any data or
+// weights are not included.
+type JSONKeys struct {
+	*Trie
+}
+
+// MarshalJSON encodes the entries of the wrapped trie as a
+// JSON array of strings.
+func (k JSONKeys) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.Entries())
+}
+
+// UnmarshalJSON replaces the contents of the wrapped trie with
+// the entries decoded from a JSON array of strings (allocating
+// the trie, if there is none).
+func (k *JSONKeys) UnmarshalJSON(b []byte) error {
+
+	var entries []string
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return err
+	}
+
+	decoded := NewTrie()
+	if k.Trie != nil && k.arena != nil {
+		decoded = NewArenaTrie()
+	} else if k.Trie != nil {
+		decoded.fold = k.fold
+	}
+	for _, s := range entries {
+		if !decoded.Insert(s) {
+			return fmt.Errorf("trie: cannot insert JSON entry %q", s)
+		}
+	}
+	if k.Trie == nil {
+		k.Trie = &decoded
+	} else {
+		*k.Trie = decoded
+	}
+	return nil
+}
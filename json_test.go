@@ -0,0 +1,230 @@
+package trie
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+
+	trie := getTrie(2, 's')
+	trie.Insert("slowly")
+	checkValid(t, "slowly", &trie)
+
+	b, err := json.Marshal(&trie)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	expected := `{"edge":"","entry":false,"children":[{"edge":"s","entry":false,"children":[{"edge":"low","entry":true,"children":[{"edge":"er","entry":true},{"edge":"ly","entry":true}]}]}]}`
+	if string(b) != expected {
+		t.Errorf("expected JSON to be %s, but was %s", expected, b)
+	}
+}
+
+func TestJSONKeysZero(t *testing.T) {
+
+	var keys JSONKeys
+	if err := json.Unmarshal([]byte(`["romane","romanus"]`), &keys); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if keys.Trie == nil || keys.Count() != 2 {
+		t.Fatalf("expected a trie with 2 entries")
+	}
+	checkValid(t, "zero JSONKeys", keys.Trie)
+	if found, _ := keys.Find("romanus"); !found {
+		t.Errorf("expected to find 'romanus'")
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+
+	roundTripTests := []struct {
+		name string
+		trie Trie
+	}{
+		{
+			name: "empty trie",
+			trie: getTrie(0, 'r'),
+		},
+		{
+			name: "'r' trie with one element",
+			trie: getTrie(1, 'r'),
+		},
+		{
+			name: "'r' trie with four elements",
+			trie: getTrie(4, 'r'),
+		},
+		{
+			name: "'r' trie with seven elements",
+			trie: getTrie(7, 'r'),
+		},
+		{
+			name: "'s' trie with three elements",
+			trie: getTrie(3, 's'),
+		},
+		{
+			name: "'t' trie with three elements",
+			trie: getTrie(3, 't'),
+		},
+		{
+			name: "chinese trie with two elements",
+			trie: getStringTrie(2, "大"),
+		},
+	}
+
+	for _, test := range roundTripTests {
+		b, err := json.Marshal(&test.trie)
+		if err != nil {
+			t.Errorf("test '%s': unexpected marshal error: %v", test.name, err)
+			continue
+		}
+		decoded := NewTrie()
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			t.Errorf("test '%s': unexpected unmarshal error: %v", test.name, err)
+			continue
+		}
+		checkValid(t, test.name, &test.trie)
+		checkValid(t, test.name, &decoded)
+		if decoded.Count() != test.trie.Count() {
+			t.Errorf("test '%s': expected count to be %d, but was %d", test.name, test.trie.Count(), decoded.Count())
+		}
+		if !reflect.DeepEqual(decoded.Entries(), test.trie.Entries()) {
+			t.Errorf("test '%s': expected entries %v, but were %v", test.name, test.trie.Entries(), decoded.Entries())
+		}
+
+		keys, err := json.Marshal(JSONKeys{&test.trie})
+		if err != nil {
+			t.Errorf("test '%s': unexpected keys marshal error: %v", test.name, err)
+			continue
+		}
+		rebuilt := NewTrie()
+		if err := json.Unmarshal(keys, &JSONKeys{&rebuilt}); err != nil {
+			t.Errorf("test '%s': unexpected keys unmarshal error: %v", test.name, err)
+			continue
+		}
+		checkValid(t, test.name, &rebuilt)
+		for _, s := range test.trie.Entries() {
+			if found, _ := rebuilt.Find(s); !found {
+				t.Errorf("test '%s': expected to find '%s' after keys round trip", test.name, s)
+			}
+		}
+	}
+}
+
+func TestJSONKeys(t *testing.T) {
+
+	trie := getTrie(3, 't')
+
+	b, err := json.Marshal(JSONKeys{&trie})
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	expected := `["test","toaster","toasting"]`
+	if string(b) != expected {
+		t.Errorf("expected JSON to be %s, but was %s", expected, b)
+	}
+}
+
+func TestJSONFolded(t *testing.T) {
+
+	trie := NewFoldedTrie()
+	for _, s := range []string{"iPhone", "IPHONE", "Straße", "test"} {
+		trie.Insert(s)
+	}
+	b, err := json.Marshal(&trie)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	decoded := NewTrie()
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	checkValid(t, "folded", &decoded)
+	if found, _ := decoded.Find("IPhone"); !found {
+		t.Errorf("expected decoded trie to be case-insensitive")
+	}
+	if !reflect.DeepEqual(decoded.Entries(), trie.Entries()) {
+		t.Errorf("expected entries %v, but were %v", trie.Entries(), decoded.Entries())
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+
+	errorTests := []struct {
+		name  string
+		json  string
+		trie  Trie
+		keys  bool
+		count int
+	}{
+		{
+			name:  "malformed JSON",
+			json:  `{"edge":`,
+			trie:  getTrie(1, 'r'),
+			count: 1,
+		},
+		{
+			name:  "root with an edge",
+			json:  `{"edge":"r","entry":false}`,
+			trie:  getTrie(1, 'r'),
+			count: 1,
+		},
+		{
+			name:  "child with an empty edge",
+			json:  `{"edge":"","entry":false,"children":[{"edge":"","entry":true}]}`,
+			trie:  getTrie(1, 'r'),
+			count: 1,
+		},
+		{
+			name:  "non-entry with a single child",
+			json:  `{"edge":"","entry":false,"children":[{"edge":"r","entry":false,"children":[{"edge":"om","entry":false,"children":[{"edge":"ane","entry":true}]}]}]}`,
+			trie:  getTrie(1, 'r'),
+			count: 1,
+		},
+		{
+			name:  "folded child",
+			json:  `{"edge":"","entry":false,"children":[{"edge":"romane","entry":true,"keys":["romane"],"folded":true}]}`,
+			trie:  getTrie(1, 'r'),
+			count: 1,
+		},
+		{
+			name:  "original terms without folding",
+			json:  `{"edge":"","entry":false,"children":[{"edge":"romane","entry":true,"keys":["Romane"]}]}`,
+			trie:  getTrie(1, 'r'),
+			count: 1,
+		},
+		{
+			name:  "duplicate keys",
+			json:  `["romane","romane"]`,
+			trie:  getTrie(1, 'r'),
+			keys:  true,
+			count: 1,
+		},
+		{
+			name:  "key too short",
+			json:  `["a"]`,
+			trie:  getTrie(1, 'r'),
+			keys:  true,
+			count: 1,
+		},
+	}
+
+	for _, test := range errorTests {
+		var err error
+		if test.keys {
+			err = json.Unmarshal([]byte(test.json), &JSONKeys{&test.trie})
+		} else {
+			err = json.Unmarshal([]byte(test.json), &test.trie)
+		}
+		if err == nil {
+			t.Errorf("test '%s': expected an error", test.name)
+		}
+		// A failed decode must leave the trie untouched
+		checkValid(t, test.name, &test.trie)
+		if test.trie.Count() != test.count {
+			t.Errorf("test '%s': expected count to be %d, but was %d", test.name, test.count, test.trie.Count())
+		}
+	}
+}
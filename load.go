@@ -0,0 +1,145 @@
+package trie
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Column describes the meaning of a tab-separated column which
+// follows the word on each line of a word list.
+type Column int
+
+const (
+	// ColumnWeight holds an integer weight for the word (see
+	// InsertWeight).
+	ColumnWeight Column = iota + 1
+
+	// ColumnData holds data for the word (see InsertData).
+	ColumnData
+)
+
+// LoadOptions controls how word lists are read by LoadWords.
+type LoadOptions struct {
+
+	// Columns lists, in order, the meaning of any tab-separated
+	// columns which follow the word on each line. If there are
+	// none then the whole line (once trimmed) is the word.
+	Columns []Column
+
+	// Comment is the prefix of lines which are to be skipped
+	// (such as "#"). If empty then no lines are comments.
+	Comment string
+}
+
+var (
+	// ErrInvalidUTF8 is reported for lines which are not valid UTF-8.
+	ErrInvalidUTF8 = errors.New("trie: invalid UTF-8")
+
+	// ErrBadColumns is reported for lines whose columns do not match
+	// those expected (including weights which are not integers).
+	ErrBadColumns = errors.New("trie: bad columns")
+)
+
+// RejectedLine describes a line of a word list which LoadWords
+// could not insert.
+type RejectedLine struct {
+	Line int    // line number, starting from 1
+	Text string // the line as read
+	Err  error  // why it was rejected
+}
+
+// LoadReport summarises the result of LoadWords.
+type LoadReport struct {
+	Loaded   int
+	Rejected []RejectedLine
+}
+
+// LoadWords inserts the words listed in r into the trie, one word
+// per line. Input which is gzipped is detected and decompressed.
+// Blank lines and comments are skipped, while lines which cannot
+// be inserted - because they are too short (ErrInvalidKey), are
+// duplicates (ErrDuplicateKey), are not valid UTF-8 or have bad
+// columns - are listed in the report rather than stopping the
+// load. An error is only returned if r could not be read.
+func (t *Trie) LoadWords(r io.Reader, opts LoadOptions) (LoadReport, error) {
+
+	report := LoadReport{}
+
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return report, err
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || (opts.Comment != "" && strings.HasPrefix(trimmed, opts.Comment)) {
+			continue
+		}
+		if err := t.loadLine(strings.TrimRight(text, "\r"), opts.Columns); err != nil {
+			report.Rejected = append(report.Rejected, RejectedLine{Line: line, Text: text, Err: err})
+			continue
+		}
+		report.Loaded++
+	}
+	return report, scanner.Err()
+}
+
+func (t *Trie) loadLine(line string, columns []Column) error {
+
+	if !utf8.ValidString(line) {
+		return ErrInvalidUTF8
+	}
+
+	fields := strings.Split(line, "\t")
+	if len(fields) != len(columns)+1 {
+		return fmt.Errorf("%w: expected %d columns, found %d", ErrBadColumns, len(columns)+1, len(fields))
+	}
+
+	word := strings.TrimSpace(fields[0])
+	if len(word) < 2 || utf8.RuneCountInString(word) < 2 {
+		return ErrInvalidKey
+	}
+
+	weight, data := 0, ""
+	for i, c := range columns {
+		field := strings.TrimSpace(fields[i+1])
+		switch c {
+		case ColumnWeight:
+			w, err := strconv.Atoi(field)
+			if err != nil {
+				return fmt.Errorf("%w: weight %q is not an integer", ErrBadColumns, field)
+			}
+			weight = w
+		case ColumnData:
+			data = field
+		default:
+			return fmt.Errorf("%w: unknown column %d", ErrBadColumns, c)
+		}
+	}
+
+	if !t.Insert(word) {
+		return ErrDuplicateKey
+	}
+	key := t.foldKey(word)
+	t.setData(key, data)
+	t.setWeight(key, weight)
+	return nil
+}
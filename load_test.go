@@ -0,0 +1,121 @@
+package trie
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadWords(t *testing.T) {
+
+	loadTests := []struct {
+		name     string
+		input    string
+		opts     LoadOptions
+		loaded   int
+		rejected []RejectedLine
+		weights  map[string]int
+		data     map[string]string
+	}{
+		{
+			name:   "one word per line",
+			input:  "romane\nromanus\r\n\n  romulus  \n",
+			opts:   LoadOptions{},
+			loaded: 3,
+		},
+		{
+			name:   "comments",
+			input:  "# latin\nromane\n  # more latin\nromanus\n",
+			opts:   LoadOptions{Comment: "#"},
+			loaded: 2,
+		},
+		{
+			name:  "rejected lines",
+			input: "romane\nr\nromane\n大\nrom\xffane\n",
+			opts:  LoadOptions{},
+			rejected: []RejectedLine{
+				{Line: 2, Text: "r", Err: ErrInvalidKey},
+				{Line: 3, Text: "romane", Err: ErrDuplicateKey},
+				{Line: 4, Text: "大", Err: ErrInvalidKey},
+				{Line: 5, Text: "rom\xffane", Err: ErrInvalidUTF8},
+			},
+			loaded: 1,
+		},
+		{
+			name:    "weights",
+			input:   "slow\t12\nslower\t3\nslowly\tmany\nslowest\n",
+			opts:    LoadOptions{Columns: []Column{ColumnWeight}},
+			loaded:  2,
+			weights: map[string]int{"slow": 12, "slower": 3},
+			rejected: []RejectedLine{
+				{Line: 3, Text: "slowly\tmany", Err: ErrBadColumns},
+				{Line: 4, Text: "slowest", Err: ErrBadColumns},
+			},
+		},
+		{
+			name:    "weights and data",
+			input:   "大蒜\t5\tgarlic\n大豆\t7\tsoybean\n",
+			opts:    LoadOptions{Columns: []Column{ColumnWeight, ColumnData}},
+			loaded:  2,
+			weights: map[string]int{"大蒜": 5, "大豆": 7},
+			data:    map[string]string{"大蒜": "garlic", "大豆": "soybean"},
+		},
+	}
+
+	for _, test := range loadTests {
+		trie := NewTrie()
+		report, err := trie.LoadWords(strings.NewReader(test.input), test.opts)
+		if err != nil {
+			t.Errorf("test '%s': unexpected error: %v", test.name, err)
+			continue
+		}
+		checkValid(t, test.name, &trie)
+		if report.Loaded != test.loaded || trie.Count() != test.loaded {
+			t.Errorf("test '%s': expected %d loaded, but was %d (count %d)", test.name, test.loaded, report.Loaded, trie.Count())
+		}
+		if len(report.Rejected) != len(test.rejected) {
+			t.Errorf("test '%s': expected %d rejected, but was %v", test.name, len(test.rejected), report.Rejected)
+			continue
+		}
+		for i, r := range report.Rejected {
+			expected := test.rejected[i]
+			if r.Line != expected.Line || r.Text != expected.Text || !errors.Is(r.Err, expected.Err) {
+				t.Errorf("test '%s': expected rejection %v, but was %v", test.name, expected, r)
+			}
+		}
+		for s, w := range test.weights {
+			if _, n := trie.Find(s); n == nil || n.Weight() != w {
+				t.Errorf("test '%s': expected weight of '%s' to be %d", test.name, s, w)
+			}
+		}
+		for s, d := range test.data {
+			if _, n := trie.Find(s); n == nil || n.Data() != d {
+				t.Errorf("test '%s': expected data of '%s' to be '%s'", test.name, s, d)
+			}
+		}
+	}
+}
+
+func TestLoadWordsGzip(t *testing.T) {
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("slow\nslower\nslowly\n"))
+	zw.Close()
+
+	trie := NewTrie()
+	report, err := trie.LoadWords(&buf, LoadOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkValid(t, "gzip", &trie)
+	if report.Loaded != 3 {
+		t.Errorf("expected 3 loaded, but was %d", report.Loaded)
+	}
+	expected := getTrie(3, 's')
+	if trie.String() != expected.String() {
+		t.Errorf("expected structure\n%s\nbut was\n%s", expected.String(), trie.String())
+	}
+}
@@ -0,0 +1,345 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// LOUDSTrie is a static, succinct encoding of the entries of a
+// trie, for when they need to be as small as possible (such as to
+// ship with an app). The shape of the trie is held as a LOUDS
+// (level-order unary degree sequence) bitvector, in which each
+// node - taken in breadth-first order - is written as one 1-bit per
+// child followed by a 0-bit. So the shape takes just over two bits
+// per node, and with rank & select over the bitvector it can be
+// navigated without any pointers. Each node is labelled with a byte,
+// packed into a single array, so keys are ordered as by string
+// comparison. Lookups are slower than in a Trie, but work directly
+// on the encoding.
+type LOUDSTrie struct {
+	louds  bitVector
+	final  bitVector
+	labels []byte // the label of node i (for i > 0) is labels[i-1]
+	count  int
+}
+
+// bitVector is a bitvector with the counts needed for rank & select.
+type bitVector struct {
+	words []uint64
+	ranks []uint32 // ranks[i] is the number of 1-bits before words[i]
+	len   int
+}
+
+func (b *bitVector) push(bit bool) {
+
+	if b.len%64 == 0 {
+		b.words = append(b.words, 0)
+	}
+	if bit {
+		b.words[b.len/64] |= 1 << (b.len % 64)
+	}
+	b.len++
+}
+
+func (b *bitVector) get(i int) bool {
+	return b.words[i/64]&(1<<(i%64)) != 0
+}
+
+// index computes the ranks, once every bit has been pushed.
+func (b *bitVector) index() {
+
+	b.ranks = make([]uint32, len(b.words)+1)
+	for i, w := range b.words {
+		b.ranks[i+1] = b.ranks[i] + uint32(bits.OnesCount64(w))
+	}
+}
+
+// rank1 returns the number of 1-bits before position i.
+func (b *bitVector) rank1(i int) int {
+
+	w := i / 64
+	r := int(b.ranks[w])
+	if i%64 != 0 {
+		r += bits.OnesCount64(b.words[w] & (1<<(i%64) - 1))
+	}
+	return r
+}
+
+// select0 returns the position of the k-th 0-bit (counting from 1),
+// or -1 if there are not that many.
+func (b *bitVector) select0(k int) int {
+
+	// Find the word holding it, from the number of 0-bits before
+	// each word
+	zeros := func(w int) int { return w*64 - int(b.ranks[w]) }
+	w := sort.Search(len(b.words), func(w int) bool { return zeros(w+1) >= k })
+	if w == len(b.words) {
+		return -1
+	}
+	k -= zeros(w)
+	word := ^b.words[w]
+	for ; k > 1; k-- {
+		word &= word - 1
+	}
+	i := w*64 + bits.TrailingZeros64(word)
+	if i >= b.len {
+		return -1
+	}
+	return i
+}
+
+// ToLOUDS encodes the entries of the trie as a LOUDSTrie. Data and
+// weights are not kept. Case-insensitive tries cannot be encoded
+// (see ErrFolded), as their entries are not the terms to be found.
+func (t *Trie) ToLOUDS() (*LOUDSTrie, error) {
+
+	if t.fold {
+		return nil, ErrFolded
+	}
+
+	keys := t.Entries()
+	sort.Strings(keys)
+
+	l := &LOUDSTrie{count: len(keys)}
+
+	// The super-root, whose only child is the root
+	l.louds.push(true)
+	l.louds.push(false)
+
+	// Each node is the range of keys which share its path, depth
+	// bytes long
+	type span struct{ lo, hi, depth int }
+	queue := []span{{0, len(keys), 0}}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		lo := n.lo
+		l.final.push(lo < n.hi && len(keys[lo]) == n.depth)
+		if lo < n.hi && len(keys[lo]) == n.depth {
+			lo++
+		}
+		for lo < n.hi {
+			c := keys[lo][n.depth]
+			hi := lo + 1
+			for hi < n.hi && keys[hi][n.depth] == c {
+				hi++
+			}
+			l.louds.push(true)
+			l.labels = append(l.labels, c)
+			queue = append(queue, span{lo, hi, n.depth + 1})
+			lo = hi
+		}
+		l.louds.push(false)
+	}
+
+	l.louds.index()
+	l.final.index()
+	return l, nil
+}
+
+// Count returns the number of entries in the trie.
+func (l *LOUDSTrie) Count() int {
+	return l.count
+}
+
+// children returns the node number of the first child of node x and
+// the number of children it has.
+func (l *LOUDSTrie) children(x int) (int, int) {
+
+	start := l.louds.select0(x+1) + 1
+	end := l.louds.select0(x + 2)
+	return l.louds.rank1(start), end - start
+}
+
+// child returns the child of node x labelled c, or -1.
+func (l *LOUDSTrie) child(x int, c byte) int {
+
+	first, n := l.children(x)
+	labels := l.labels[first-1 : first-1+n]
+	i := sort.Search(n, func(i int) bool { return labels[i] >= c })
+	if i < n && labels[i] == c {
+		return first + i
+	}
+	return -1
+}
+
+// walk follows s from the root, returning the node reached (or -1
+// if s leads nowhere).
+func (l *LOUDSTrie) walk(s string) int {
+
+	x := 0
+	for i := 0; i < len(s) && x >= 0; i++ {
+		x = l.child(x, s[i])
+	}
+	return x
+}
+
+// Find is used to search for a specific term in the trie.
+func (l *LOUDSTrie) Find(s string) bool {
+
+	// Remove leading & trailing whitespace
+	trimmed := strings.TrimSpace(s)
+
+	// Sanity check (should catch empty strings too)
+	if len(trimmed) < 2 {
+		return false
+	}
+
+	x := l.walk(trimmed)
+	return x >= 0 && l.final.get(x)
+}
+
+// WithPrefix returns every entry which begins with prefix, in sorted
+// order. As with Trie, the prefix is not trimmed.
+func (l *LOUDSTrie) WithPrefix(prefix string) []string {
+
+	entries := []string{}
+	if x := l.walk(prefix); x >= 0 {
+		l.collect(x, []byte(prefix), &entries)
+	}
+	return entries
+}
+
+func (l *LOUDSTrie) collect(x int, path []byte, entries *[]string) {
+
+	if l.final.get(x) {
+		*entries = append(*entries, string(path))
+	}
+	first, n := l.children(x)
+	for i := 0; i < n; i++ {
+		l.collect(first+i, append(path, l.labels[first+i-1]), entries)
+	}
+}
+
+// Entries returns every entry in the trie, in sorted order.
+func (l *LOUDSTrie) Entries() []string {
+	return l.WithPrefix("")
+}
+
+// The binary encoding of a LOUDSTrie is laid out as follows:
+//
+//	magic    4 bytes ("RDXL")
+//	version  1 byte
+//	body     the entry count and the number of nodes (both as
+//	         uvarints), then the LOUDS & final bitvectors (as
+//	         little-endian 64-bit words) and the labels
+//	checksum 4 bytes (big-endian CRC-32 of magic, version & body)
+//
+// The ranks are not stored, as they are quick to recompute.
+const (
+	loudsMagic   = "RDXL"
+	loudsVersion = 1
+)
+
+// MarshalBinary encodes the trie into a compact, versioned and
+// checksummed binary form, which can be reloaded with
+// UnmarshalBinary.
+func (l *LOUDSTrie) MarshalBinary() ([]byte, error) {
+
+	out := append([]byte(loudsMagic), loudsVersion)
+	out = binary.AppendUvarint(out, uint64(l.count))
+	out = binary.AppendUvarint(out, uint64(l.final.len))
+	for _, w := range l.louds.words {
+		out = binary.LittleEndian.AppendUint64(out, w)
+	}
+	for _, w := range l.final.words {
+		out = binary.LittleEndian.AppendUint64(out, w)
+	}
+	out = append(out, l.labels...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out)), nil
+}
+
+// UnmarshalBinary replaces the contents of the trie with those
+// decoded from data, which must have come from MarshalBinary.
+func (l *LOUDSTrie) UnmarshalBinary(data []byte) error {
+
+	if len(data) < len(loudsMagic)+1+4 || !bytes.HasPrefix(data, []byte(loudsMagic)) {
+		return ErrBadMagic
+	}
+	body, sum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return ErrChecksum
+	}
+	if body[len(loudsMagic)] != loudsVersion {
+		return ErrBadVersion
+	}
+	body = body[len(loudsMagic)+1:]
+
+	count, n := binary.Uvarint(body)
+	if n <= 0 {
+		return ErrCorrupt
+	}
+	body = body[n:]
+	nodes, n := binary.Uvarint(body)
+	if n <= 0 || nodes == 0 || nodes > uint64(len(body)) {
+		return ErrCorrupt
+	}
+	body = body[n:]
+
+	// There are 2n+1 bits of LOUDS, n final bits and n-1 labels
+	decoded := LOUDSTrie{count: int(count)}
+	words := func(b *bitVector, bits int) bool {
+		b.len = bits
+		b.words = make([]uint64, (bits+63)/64)
+		if len(body) < 8*len(b.words) {
+			return false
+		}
+		for i := range b.words {
+			b.words[i] = binary.LittleEndian.Uint64(body[8*i:])
+		}
+		body = body[8*len(b.words):]
+		// Any bits beyond the end must be clear
+		if bits%64 != 0 && b.words[len(b.words)-1]>>(bits%64) != 0 {
+			return false
+		}
+		b.index()
+		return true
+	}
+	if !words(&decoded.louds, int(2*nodes+1)) || !words(&decoded.final, int(nodes)) {
+		return ErrCorrupt
+	}
+	if uint64(len(body)) != nodes-1 {
+		return ErrCorrupt
+	}
+	decoded.labels = append([]byte{}, body...)
+
+	if err := decoded.validate(int(nodes)); err != nil {
+		return err
+	}
+	*l = decoded
+	return nil
+}
+
+// validate checks that the LOUDS bitvector describes a tree of n
+// nodes (so that navigating it stays in bounds), with sorted labels
+// and as many final nodes as entries.
+func (l *LOUDSTrie) validate(n int) error {
+
+	if l.louds.ranks[len(l.louds.words)] != uint32(n) || l.final.ranks[len(l.final.words)] != uint32(l.count) {
+		return ErrCorrupt
+	}
+	if !l.louds.get(0) || l.louds.get(1) || l.louds.get(2*n) {
+		return ErrCorrupt
+	}
+
+	// Every node must already have been reached (by a 1-bit) before
+	// its list of children ends with a 0-bit
+	for x := 1; x <= n; x++ {
+		end := l.louds.select0(x + 1)
+		if end < 0 || l.louds.rank1(end) < x {
+			return ErrCorrupt
+		}
+		first, c := l.children(x - 1)
+		for i := 1; i < c; i++ {
+			if l.labels[first+i-2] >= l.labels[first+i-1] {
+				return ErrCorrupt
+			}
+		}
+	}
+	return nil
+}
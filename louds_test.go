@@ -0,0 +1,176 @@
+package trie
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLOUDS(t *testing.T) {
+
+	trie := getTrie(7, 'r')
+	for _, s := range []string{"slow", "slower", "slowly", "大豆", "黄豆", "大米"} {
+		trie.Insert(s)
+	}
+	l, err := trie.ToLOUDS()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := trie.Entries()
+	slices.Sort(entries)
+	if !reflect.DeepEqual(l.Entries(), entries) {
+		t.Errorf("expected entries %v, but was %v", entries, l.Entries())
+	}
+	if l.Count() != trie.Count() {
+		t.Errorf("expected count to be %d, but was %d", trie.Count(), l.Count())
+	}
+
+	findTests := []struct {
+		value string
+		found bool
+	}{
+		{value: "romane", found: true},
+		{value: " slow\n", found: true},
+		{value: "rubicundus", found: true},
+		{value: "大米", found: true},
+		{value: "roman", found: false},
+		{value: "slowest", found: false},
+		{value: "大", found: false},
+		{value: "z", found: false},
+		{value: "", found: false},
+	}
+
+	for _, test := range findTests {
+		if found := l.Find(test.value); found != test.found {
+			t.Errorf("find '%s': expected found to be %t", test.value, test.found)
+		}
+	}
+
+	prefixTests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{prefix: "rub", expected: []string{"rubens", "ruber", "rubicon", "rubicundus"}},
+		{prefix: "slowl", expected: []string{"slowly"}},
+		{prefix: "大", expected: []string{"大米", "大豆"}},
+		{prefix: "x", expected: []string{}},
+	}
+
+	for _, test := range prefixTests {
+		if found := l.WithPrefix(test.prefix); !reflect.DeepEqual(found, test.expected) {
+			t.Errorf("prefix '%s': expected %v, but was %v", test.prefix, test.expected, found)
+		}
+	}
+}
+
+func TestLOUDSEmpty(t *testing.T) {
+
+	trie := NewTrie()
+	l, err := trie.ToLOUDS()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l.Count() != 0 || len(l.Entries()) != 0 || l.Find("ab") {
+		t.Errorf("expected an empty LOUDS trie")
+	}
+}
+
+func TestLOUDSFolded(t *testing.T) {
+
+	// Its entries are every original term, which would not be found
+	// by the folded term (nor counted as one entry)
+	trie := NewFoldedTrie()
+	trie.Insert("iPhone")
+	trie.Insert("IPHONE")
+	if l, err := trie.ToLOUDS(); err != ErrFolded || l != nil {
+		t.Errorf("expected error to be %v, but was %v", ErrFolded, err)
+	}
+}
+
+func TestLOUDSLarge(t *testing.T) {
+
+	// Enough nodes to span many words of the bitvectors
+	trie := NewTrie()
+	for _, s := range benchmarkKeys {
+		trie.Insert(s)
+	}
+	l, err := trie.ToLOUDS()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, s := range benchmarkKeys {
+		if !l.Find(s) {
+			t.Fatalf("expected to find '%s'", s)
+		}
+		if l.Find(s + "x") {
+			t.Fatalf("expected not to find '%sx'", s)
+		}
+	}
+	var expected []string
+	for _, s := range benchmarkKeys {
+		if strings.HasPrefix(s, "cka") || strings.HasPrefix(s, "fq") {
+			expected = append(expected, s)
+		}
+	}
+	if found := append(l.WithPrefix("cka"), l.WithPrefix("fq")...); !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected %d entries with the prefixes, but was %d", len(expected), len(found))
+	}
+}
+
+func TestLOUDSBinary(t *testing.T) {
+
+	trie := NewTrie()
+	for _, s := range benchmarkKeys {
+		trie.Insert(s)
+	}
+	l, err := trie.ToLOUDS()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := l.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	encoded, _ := trie.MarshalBinary()
+	if len(data) >= len(encoded) {
+		t.Errorf("expected the LOUDS encoding (%d bytes) to be smaller than the trie's (%d bytes)", len(data), len(encoded))
+	}
+
+	var decoded LOUDSTrie
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Entries(), l.Entries()) {
+		t.Errorf("expected the decoded entries to match")
+	}
+
+	// Flipping a bit of the LOUDS shape (and fixing the checksum)
+	// must be caught by the structural checks
+	reshaped := append([]byte{}, data[:len(data)-4]...)
+	reshaped[16] ^= 0x01
+	reshaped = binary.BigEndian.AppendUint32(reshaped, crc32.ChecksumIEEE(reshaped))
+
+	errorTests := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{name: "empty", data: []byte{}, expected: ErrBadMagic},
+		{name: "other magic", data: append([]byte("RDXT"), data[4:]...), expected: ErrBadMagic},
+		{name: "bad shape", data: reshaped, expected: ErrCorrupt},
+		{name: "corrupted", data: append(append([]byte{}, data[:20]...), append([]byte{data[20] ^ 0xff}, data[21:]...)...), expected: ErrChecksum},
+	}
+
+	for _, test := range errorTests {
+		if err := decoded.UnmarshalBinary(test.data); !errors.Is(err, test.expected) {
+			t.Errorf("test '%s': expected %v, but was %v", test.name, test.expected, err)
+		}
+	}
+}
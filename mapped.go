@@ -0,0 +1,367 @@
+package trie
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// The mapped layout is flat and pointer-free, so that it can be
+// queried in place. All integers are little-endian uint32s and
+// every record starts on a four-byte boundary:
+//
+//	header  magic ("RDXM"), version byte & 3 bytes of padding,
+//	        entry count, root count, then one offset per root
+//	node    flags byte & 3 bytes of padding, edge length, data
+//	        length, child count, then one offset per child,
+//	        followed by the edge & data bytes and padding
+//
+// Offsets are from the start of the file. Nodes are laid out in
+// pre-order, so a child always follows its parent.
+const (
+	mappedMagic     = "RDXM"
+	mappedVersion   = 1
+	mappedHeaderLen = 16
+	mappedNodeLen   = 16
+)
+
+// ErrTooLarge is returned when a trie is too large to be written
+// in the mapped layout, which uses 32-bit offsets.
+var ErrTooLarge = errors.New("trie: too large for mapped layout")
+
+// WriteMapped writes the trie to w in the flat layout which is
+// read by OpenMapped. It returns the number of bytes written.
+// Case-insensitive tries cannot be written (see ErrFolded).
+func (t *Trie) WriteMapped(w io.Writer) (int64, error) {
+
+	if t.fold {
+		return 0, ErrFolded
+	}
+	t = t.tree()
+
+	// First pass: lay the nodes out in pre-order
+	var nodes []*Node
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		nodes = append(nodes, n)
+		for _, c := range n.children {
+			visit(c)
+		}
+	}
+	for _, c := range t.child {
+		visit(c)
+	}
+
+	offsets := make(map[*Node]uint32, len(nodes))
+	size := uint64(align4(mappedHeaderLen + 4*len(t.child)))
+	for _, n := range nodes {
+		if size > math.MaxUint32 {
+			return 0, ErrTooLarge
+		}
+		offsets[n] = uint32(size)
+		size += uint64(mappedRecordLen(n))
+	}
+	if size > math.MaxUint32 {
+		return 0, ErrTooLarge
+	}
+
+	// Second pass: emit them
+	out := make([]byte, 0, size)
+	out = append(out, mappedMagic...)
+	out = append(out, mappedVersion, 0, 0, 0)
+	out = binary.LittleEndian.AppendUint32(out, uint32(t.count))
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(t.child)))
+	for _, c := range t.child {
+		out = binary.LittleEndian.AppendUint32(out, offsets[c])
+	}
+	out = pad4(out)
+	for _, n := range nodes {
+		var flags byte
+		if n.entry {
+			flags |= flagEntry
+		}
+		out = append(out, flags, 0, 0, 0)
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(n.value)))
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(n.data)))
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(n.children)))
+		for _, c := range n.children {
+			out = binary.LittleEndian.AppendUint32(out, offsets[c])
+		}
+		out = append(out, n.value...)
+		out = append(out, n.data...)
+		out = pad4(out)
+	}
+
+	written, err := w.Write(out)
+	return int64(written), err
+}
+
+func mappedRecordLen(n *Node) int {
+	return align4(mappedNodeLen + 4*len(n.children) + len(n.value) + len(n.data))
+}
+
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
+
+func pad4(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// MappedTrie is a read-only trie which is queried directly
+// against the flat layout written by WriteMapped, without being
+// decoded into nodes. When opened with OpenMapped the layout is
+// memory-mapped (where the platform allows), so that the pages
+// are shared by every process which opens the same file.
+//
+// Weights are not included in the layout. Only the header is
+// checked when a MappedTrie is created. Every other read is
+// bounds-checked, so that a corrupt layout answers queries as
+// though entries were missing rather than panicking.
+type MappedTrie struct {
+	data  []byte
+	roots []byte
+	close func() error
+}
+
+// NewMappedTrie returns a MappedTrie which reads the layout held
+// in data. The slice must not be modified while it is in use.
+func NewMappedTrie(data []byte) (*MappedTrie, error) {
+
+	if len(data) < mappedHeaderLen || string(data[:len(mappedMagic)]) != mappedMagic {
+		return nil, ErrBadMagic
+	}
+	if data[len(mappedMagic)] != mappedVersion {
+		return nil, ErrBadVersion
+	}
+	roots := uint64(binary.LittleEndian.Uint32(data[12:]))
+	if mappedHeaderLen+4*roots > uint64(len(data)) {
+		return nil, ErrCorrupt
+	}
+	return &MappedTrie{data: data, roots: data[mappedHeaderLen : mappedHeaderLen+4*roots]}, nil
+}
+
+// Close releases the mapping (if any). The MappedTrie must not
+// be used after it has been closed.
+func (m *MappedTrie) Close() error {
+
+	m.data, m.roots = nil, nil
+	if m.close == nil {
+		return nil
+	}
+	fn := m.close
+	m.close = nil
+	return fn()
+}
+
+// Count returns the number of entries in the trie.
+func (m *MappedTrie) Count() int {
+
+	if m.data == nil {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint32(m.data[8:]))
+}
+
+// Find is used to search for a specific term in the trie. As
+// with Trie.Find, leading & trailing whitespace is ignored.
+func (m *MappedTrie) Find(s string) bool {
+
+	_, ok := m.find(s)
+	return ok
+}
+
+func (m *MappedTrie) find(s string) (mappedNode, bool) {
+
+	trimmed := strings.TrimSpace(s)
+	if len(trimmed) < 2 {
+		return mappedNode{}, false
+	}
+
+	n, ok := m.root(trimmed)
+	if !ok {
+		return mappedNode{}, false
+	}
+	rest := trimmed[len(n.edge):]
+	for rest != "" {
+		if n, ok = m.childWithPrefixOf(n, rest); !ok {
+			return mappedNode{}, false
+		}
+		rest = rest[len(n.edge):]
+	}
+	return n, n.entry()
+}
+
+// Data returns the data stored with the entry s (as for Node.Data)
+// and whether s was found.
+func (m *MappedTrie) Data(s string) (string, bool) {
+
+	n, ok := m.find(s)
+	if !ok {
+		return "", false
+	}
+	return string(n.data), true
+}
+
+// WithPrefix returns every entry which begins with prefix, as
+// for Trie.WithPrefix.
+func (m *MappedTrie) WithPrefix(prefix string) []string {
+
+	entries := []string{}
+	if prefix == "" {
+		for i := 0; i < len(m.roots)/4; i++ {
+			if n, ok := m.node(binary.LittleEndian.Uint32(m.roots[4*i:]), 0); ok {
+				entries = m.collect(n, string(n.edge), entries)
+			}
+		}
+		return entries
+	}
+
+	n, ok := m.root(prefix)
+	if !ok {
+		return entries
+	}
+	path := string(n.edge)
+	rest := prefix[len(n.edge):]
+	for rest != "" {
+		next, ok := m.childWithPrefixOf(n, rest)
+		if !ok {
+			// The prefix may end part way along an edge
+			next, ok = m.childPrefixedBy(n, rest)
+			if !ok {
+				return entries
+			}
+			return m.collect(next, path+string(next.edge), entries)
+		}
+		n = next
+		path += string(n.edge)
+		rest = rest[len(n.edge):]
+	}
+	return m.collect(n, path, entries)
+}
+
+// LongestPrefix returns the longest entry which is a prefix of
+// s, as for Trie.LongestPrefix.
+func (m *MappedTrie) LongestPrefix(s string) (string, bool) {
+
+	n, ok := m.root(s)
+	if !ok {
+		return "", false
+	}
+	longest, found := "", false
+	matched := len(n.edge)
+	for {
+		if n.entry() {
+			longest, found = s[:matched], true
+		}
+		if n, ok = m.childWithPrefixOf(n, s[matched:]); !ok {
+			return longest, found
+		}
+		matched += len(n.edge)
+	}
+}
+
+// mappedNode is a view of a single node record.
+type mappedNode struct {
+	offset   uint32
+	flags    byte
+	edge     []byte
+	data     []byte
+	children []byte
+}
+
+func (n mappedNode) entry() bool {
+	return n.flags&flagEntry != 0
+}
+
+func (n mappedNode) childCount() int {
+	return len(n.children) / 4
+}
+
+func (n mappedNode) child(i int) uint32 {
+	return binary.LittleEndian.Uint32(n.children[4*i:])
+}
+
+// node returns the record at offset, which must lie beyond the
+// parent (so that a corrupt layout cannot loop forever).
+func (m *MappedTrie) node(offset uint32, parent uint32) (mappedNode, bool) {
+
+	o := uint64(offset)
+	if o <= uint64(parent) || o%4 != 0 || o+mappedNodeLen > uint64(len(m.data)) {
+		return mappedNode{}, false
+	}
+	rec := m.data[o:]
+	edgeLen := uint64(binary.LittleEndian.Uint32(rec[4:]))
+	dataLen := uint64(binary.LittleEndian.Uint32(rec[8:]))
+	childCount := uint64(binary.LittleEndian.Uint32(rec[12:]))
+	end := mappedNodeLen + 4*childCount + edgeLen + dataLen
+	if end > uint64(len(rec)) {
+		return mappedNode{}, false
+	}
+	edgeStart := mappedNodeLen + 4*childCount
+	return mappedNode{
+		offset:   offset,
+		flags:    rec[0],
+		edge:     rec[edgeStart : edgeStart+edgeLen],
+		data:     rec[edgeStart+edgeLen : end],
+		children: rec[mappedNodeLen:edgeStart],
+	}, true
+}
+
+func (m *MappedTrie) root(s string) (mappedNode, bool) {
+
+	if s == "" {
+		return mappedNode{}, false
+	}
+	_, size := utf8.DecodeRuneInString(s)
+	for i := 0; i < len(m.roots)/4; i++ {
+		n, ok := m.node(binary.LittleEndian.Uint32(m.roots[4*i:]), 0)
+		if ok && string(n.edge) == s[:size] {
+			return n, true
+		}
+	}
+	return mappedNode{}, false
+}
+
+// childWithPrefixOf returns the child whose edge is a prefix of s.
+func (m *MappedTrie) childWithPrefixOf(n mappedNode, s string) (mappedNode, bool) {
+
+	for i := 0; i < n.childCount(); i++ {
+		c, ok := m.node(n.child(i), n.offset)
+		if ok && len(c.edge) > 0 && len(c.edge) <= len(s) && string(c.edge) == s[:len(c.edge)] {
+			return c, true
+		}
+	}
+	return mappedNode{}, false
+}
+
+// childPrefixedBy returns the child whose edge begins with s.
+func (m *MappedTrie) childPrefixedBy(n mappedNode, s string) (mappedNode, bool) {
+
+	for i := 0; i < n.childCount(); i++ {
+		c, ok := m.node(n.child(i), n.offset)
+		if ok && len(s) <= len(c.edge) && string(c.edge[:len(s)]) == s {
+			return c, true
+		}
+	}
+	return mappedNode{}, false
+}
+
+func (m *MappedTrie) collect(n mappedNode, path string, entries []string) []string {
+
+	if n.entry() {
+		entries = append(entries, path)
+	}
+	for i := 0; i < n.childCount(); i++ {
+		if c, ok := m.node(n.child(i), n.offset); ok {
+			entries = m.collect(c, path+string(c.edge), entries)
+		}
+	}
+	return entries
+}
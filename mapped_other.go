@@ -0,0 +1,18 @@
+//go:build !unix
+
+package trie
+
+import "os"
+
+// OpenMapped reads the file at path, which must have been written
+// by WriteMapped, and returns a MappedTrie which queries it in
+// place. This platform does not support memory-mapping, so the
+// file is read into memory instead (and so is not shared).
+func OpenMapped(path string) (*MappedTrie, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewMappedTrie(data)
+}
@@ -0,0 +1,109 @@
+package trie
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpenMapped(t *testing.T) {
+
+	trie := getTrie(7, 'r')
+	trie.Insert("slow")
+	trie.Insert("slowly")
+	trie.InsertData("大豆", "soybean")
+	checkValid(t, "mapped", &trie)
+
+	path := filepath.Join(t.TempDir(), "trie.map")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("unexpected create error: %v", err)
+	}
+	if _, err := trie.WriteMapped(f); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	m, err := OpenMapped(path)
+	if err != nil {
+		t.Fatalf("unexpected open error: %v", err)
+	}
+	defer m.Close()
+
+	if m.Count() != trie.Count() {
+		t.Errorf("expected count to be %d, but was %d", trie.Count(), m.Count())
+	}
+
+	for _, s := range []string{"romane", "rubicundus", " slow\n", "slowly", "大豆", "roman", "rubic", "slowest", "大", "xx"} {
+		found, _ := trie.Find(s)
+		if m.Find(s) != found {
+			t.Errorf("find '%s': expected found to be %t", s, found)
+		}
+	}
+
+	for _, prefix := range []string{"", "r", "rub", "romu", "slow", "大", "x"} {
+		expected := trie.WithPrefix(prefix)
+		if entries := m.WithPrefix(prefix); !reflect.DeepEqual(entries, expected) {
+			t.Errorf("prefix '%s': expected %v, but was %v", prefix, expected, entries)
+		}
+	}
+
+	for _, s := range []string{"romanesque", "slowness", "rub", "大豆油", ""} {
+		expected, expectedFound := trie.LongestPrefix(s)
+		if longest, found := m.LongestPrefix(s); longest != expected || found != expectedFound {
+			t.Errorf("longest prefix '%s': expected ('%s', %t), but was ('%s', %t)", s, expected, expectedFound, longest, found)
+		}
+	}
+
+	if data, found := m.Data("大豆"); !found || data != "soybean" {
+		t.Errorf("expected data to be 'soybean', but was '%s'", data)
+	}
+}
+
+func TestNewMappedTrieCorrupt(t *testing.T) {
+
+	trie := getTrie(7, 'r')
+	var buf bytes.Buffer
+	trie.WriteMapped(&buf)
+	good := buf.Bytes()
+
+	if _, err := NewMappedTrie(good[:8]); err != ErrBadMagic {
+		t.Errorf("expected error to be %v, but was %v", ErrBadMagic, err)
+	}
+
+	bad := append([]byte(nil), good...)
+	bad[len(mappedMagic)]++
+	if _, err := NewMappedTrie(bad); err != ErrBadVersion {
+		t.Errorf("expected error to be %v, but was %v", ErrBadVersion, err)
+	}
+
+	// Truncated layouts must not panic, just fail to find entries
+	for i := mappedHeaderLen + 4; i < len(good); i += 4 {
+		m, err := NewMappedTrie(good[:i])
+		if err != nil {
+			t.Fatalf("unexpected error for %d bytes: %v", i, err)
+		}
+		if m.Find("rubicundus") {
+			t.Errorf("expected 'rubicundus' not to be found in %d bytes", i)
+		}
+		m.WithPrefix("r")
+		m.LongestPrefix("rubicundus")
+	}
+}
+
+func TestWriteMappedFolded(t *testing.T) {
+
+	trie := NewFoldedTrie()
+	trie.Insert("iPhone")
+	var buf bytes.Buffer
+	if _, err := trie.WriteMapped(&buf); err != ErrFolded {
+		t.Errorf("expected error to be %v, but was %v", ErrFolded, err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing to be written, but %d bytes were", buf.Len())
+	}
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package trie
+
+import (
+	"os"
+	"syscall"
+)
+
+// OpenMapped memory-maps the file at path, which must have been
+// written by WriteMapped, and returns a MappedTrie which queries
+// it in place. The file should be closed with Close.
+func OpenMapped(path string) (*MappedTrie, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size < mappedHeaderLen {
+		return nil, ErrBadMagic
+	}
+	if size != int64(int(size)) {
+		return nil, ErrTooLarge
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := NewMappedTrie(data)
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, err
+	}
+	m.close = func() error {
+		return syscall.Munmap(data)
+	}
+	return m, nil
+}
@@ -0,0 +1,249 @@
+package trie
+
+import (
+	"bufio"
+	"io"
+	"unicode/utf8"
+)
+
+// Match is an occurrence of an entry found by a Matcher. Start and
+// End are the byte offsets of the occurrence in the input.
+type Match struct {
+	Key   string
+	Start int
+	End   int
+}
+
+// Matcher finds every occurrence of the entries of a trie within
+// some input, in a single pass, using the Aho-Corasick algorithm.
+//
+// The automaton is the trie itself: its states are positions in the
+// trie - the root, or the end of a rune part way along (or at the
+// end of) the compressed value of a node - and moving from one to
+// the next follows the nodes. Only the failure and output links are
+// held by the Matcher, one of each per position, so the trie must
+// not be changed while the Matcher is in use.
+type Matcher struct {
+	trie  *Trie
+	nodes []acNode
+	kids  []int32 // the nodes of the children of each node, by index
+	roots []int32 // the nodes of the root rune nodes, by index
+	pos   []acPos // position 0 is the root
+	fail  []int32
+	out   []int32 // the next entry along the failure links, or -1
+}
+
+// acNode is a node of the trie, whose positions follow on from
+// first (one per rune of its value).
+type acNode struct {
+	n     *Node
+	first int32
+	kids  int32  // the offset of its children in Matcher.kids
+	key   string // the entry ending here, if any
+}
+
+// acPos is the position just after the rune ending at byte off of
+// the value of a node.
+type acPos struct {
+	node int32
+	off  int32
+}
+
+// NewMatcher builds a Matcher for the entries of t.
+func NewMatcher(t *Trie) *Matcher {
+
+	t = t.tree()
+	m := &Matcher{trie: t, pos: []acPos{{node: -1}}}
+	for _, c := range t.child {
+		m.roots = append(m.roots, m.addNode(c, ""))
+	}
+	m.link()
+	return m
+}
+
+// addNode numbers the positions along n and everything below it,
+// returning the index of n.
+func (m *Matcher) addNode(n *Node, path string) int32 {
+
+	i := int32(len(m.nodes))
+	m.nodes = append(m.nodes, acNode{n: n, first: int32(len(m.pos))})
+	for off := 0; off < len(n.value); {
+		_, size := utf8.DecodeRuneInString(n.value[off:])
+		off += size
+		m.pos = append(m.pos, acPos{node: i, off: int32(off)})
+	}
+	path += n.value
+	if n.entry {
+		m.nodes[i].key = path
+	}
+
+	// Reserve the children's slots first, so that they are together
+	kids := len(m.kids)
+	m.nodes[i].kids = int32(kids)
+	m.kids = append(m.kids, make([]int32, len(n.children))...)
+	for j, c := range n.children {
+		m.kids[kids+j] = m.addNode(c, path)
+	}
+	return i
+}
+
+// next returns the position reached from p by r, or -1.
+func (m *Matcher) next(p int32, r rune) int32 {
+
+	if p == 0 {
+		i := m.trie.roots.find(m.trie.child, r)
+		if i < 0 {
+			return -1
+		}
+		return m.nodes[m.roots[i]].first
+	}
+
+	at := m.pos[p]
+	node := &m.nodes[at.node]
+	if rest := node.n.value[at.off:]; rest != "" {
+		if c, _ := utf8.DecodeRuneInString(rest); c == r {
+			return p + 1
+		}
+		return -1
+	}
+	i := node.n.index.find(node.n.children, r)
+	if i < 0 {
+		return -1
+	}
+	return m.nodes[m.kids[node.kids+int32(i)]].first
+}
+
+// each calls fn for every position which follows p, with the rune
+// which leads to it.
+func (m *Matcher) each(p int32, fn func(r rune, to int32)) {
+
+	if p == 0 {
+		for _, i := range m.roots {
+			r, _ := utf8.DecodeRuneInString(m.nodes[i].n.value)
+			fn(r, m.nodes[i].first)
+		}
+		return
+	}
+
+	at := m.pos[p]
+	node := &m.nodes[at.node]
+	if rest := node.n.value[at.off:]; rest != "" {
+		r, _ := utf8.DecodeRuneInString(rest)
+		fn(r, p+1)
+		return
+	}
+	for j := range node.n.children {
+		c := &m.nodes[m.kids[node.kids+int32(j)]]
+		r, _ := utf8.DecodeRuneInString(c.n.value)
+		fn(r, c.first)
+	}
+}
+
+// key returns the entry ending at p, if any.
+func (m *Matcher) key(p int32) string {
+
+	if p == 0 {
+		return ""
+	}
+	at := m.pos[p]
+	node := &m.nodes[at.node]
+	if int(at.off) != len(node.n.value) {
+		return ""
+	}
+	return node.key
+}
+
+// link sets the failure and output links, breadth first.
+func (m *Matcher) link() {
+
+	m.fail = make([]int32, len(m.pos))
+	m.out = make([]int32, len(m.pos))
+	m.out[0] = -1
+
+	queue := []int32{0}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		m.each(p, func(r rune, to int32) {
+			if p != 0 {
+				m.fail[to] = m.step(m.fail[p], r)
+			}
+			if fail := m.fail[to]; m.key(fail) != "" {
+				m.out[to] = fail
+			} else {
+				m.out[to] = m.out[fail]
+			}
+			queue = append(queue, to)
+		})
+	}
+}
+
+// step follows the trie & failure links for r from p.
+func (m *Matcher) step(p int32, r rune) int32 {
+
+	for {
+		if to := m.next(p, r); to >= 0 {
+			return to
+		}
+		if p == 0 {
+			return 0
+		}
+		p = m.fail[p]
+	}
+}
+
+// emit reports every entry which ends at p, longest first.
+func (m *Matcher) emit(p int32, end int, fn func(Match)) {
+
+	if key := m.key(p); key != "" {
+		fn(Match{Key: key, Start: end - len(key), End: end})
+	}
+	for o := m.out[p]; o >= 0; o = m.out[o] {
+		key := m.key(o)
+		fn(Match{Key: key, Start: end - len(key), End: end})
+	}
+}
+
+// FindAll returns every occurrence of every entry in s, ordered by
+// where they end (and longest first for those ending together).
+// Occurrences may overlap.
+func (m *Matcher) FindAll(s string) []Match {
+
+	var matches []Match
+	collect := func(match Match) {
+		matches = append(matches, match)
+	}
+	state := int32(0)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		state = m.step(state, r)
+		m.emit(state, i, collect)
+	}
+	return matches
+}
+
+// Scan reads r until EOF, calling fn for each occurrence of each
+// entry in the order described for FindAll. Offsets are in bytes
+// from the start of r. Invalid UTF-8 is read as U+FFFD, but the
+// offsets still count the bytes which were actually read.
+func (m *Matcher) Scan(r io.Reader, fn func(Match)) error {
+
+	br, ok := r.(io.RuneReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	state, offset := int32(0), 0
+	for {
+		c, size, err := br.ReadRune()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		offset += size
+		state = m.step(state, c)
+		m.emit(state, offset, fn)
+	}
+}
@@ -0,0 +1,119 @@
+package trie
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestMatcherFindAll(t *testing.T) {
+
+	trie := NewTrie()
+	for _, s := range []string{"he", "she", "his", "hers", "slow", "slowly", "大豆", "豆油"} {
+		trie.Insert(s)
+	}
+	checkValid(t, "matcher", &trie)
+	matcher := NewMatcher(&trie)
+
+	// One position per rune along the edges, plus the root
+	runes := 0
+	var count func(n *Node)
+	count = func(n *Node) {
+		runes += utf8.RuneCountInString(n.value)
+		for _, c := range n.children {
+			count(c)
+		}
+	}
+	for _, c := range trie.child {
+		count(c)
+	}
+	if len(matcher.pos) != runes+1 {
+		t.Errorf("expected %d positions, but there were %d", runes+1, len(matcher.pos))
+	}
+
+	matchTests := []struct {
+		name     string
+		input    string
+		expected []Match
+	}{
+		{
+			name:     "no input",
+			input:    "",
+			expected: nil,
+		},
+		{
+			name:  "classic example",
+			input: "ushers",
+			expected: []Match{
+				{Key: "she", Start: 1, End: 4},
+				{Key: "he", Start: 2, End: 4},
+				{Key: "hers", Start: 2, End: 6},
+			},
+		},
+		{
+			name:  "entries along one compressed edge",
+			input: "drive slowly",
+			expected: []Match{
+				{Key: "slow", Start: 6, End: 10},
+				{Key: "slowly", Start: 6, End: 12},
+			},
+		},
+		{
+			name:  "failure part way along a compressed edge",
+			input: "sloshe",
+			expected: []Match{
+				{Key: "she", Start: 3, End: 6},
+				{Key: "he", Start: 4, End: 6},
+			},
+		},
+		{
+			name:  "overlapping chinese entries",
+			input: "买大豆油",
+			expected: []Match{
+				{Key: "大豆", Start: 3, End: 9},
+				{Key: "豆油", Start: 6, End: 12},
+			},
+		},
+		{
+			name:  "invalid UTF-8 in the input",
+			input: "\xffhis",
+			expected: []Match{
+				{Key: "his", Start: 1, End: 4},
+			},
+		},
+	}
+
+	for _, test := range matchTests {
+		matches := matcher.FindAll(test.input)
+		if !reflect.DeepEqual(matches, test.expected) {
+			t.Errorf("test '%s': expected %v, but was %v", test.name, test.expected, matches)
+		}
+
+		var scanned []Match
+		if err := matcher.Scan(strings.NewReader(test.input), func(m Match) {
+			scanned = append(scanned, m)
+		}); err != nil {
+			t.Errorf("test '%s': unexpected scan error: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(scanned, test.expected) {
+			t.Errorf("test '%s': expected scan %v, but was %v", test.name, test.expected, scanned)
+		}
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestMatcherScanError(t *testing.T) {
+
+	trie := getTrie(3, 's')
+	matcher := NewMatcher(&trie)
+	if err := matcher.Scan(failingReader{}, func(Match) {}); err == nil {
+		t.Errorf("expected an error")
+	}
+}
@@ -1,7 +1,8 @@
 package trie
 
 import (
-//"fmt"
+	//"fmt"
+	"unicode/utf8"
 )
 
 // Node is a radix trie node (which may also be a leaf).
@@ -10,6 +11,10 @@ type Node struct {
 	children   []*Node
 	childCount int
 	entry      bool
+	data       string
+	weight     int
+	keys       []string
+	index      *childIndex
 }
 
 // IsEntry may be called to determine if the current node is
@@ -20,6 +25,28 @@ func (n *Node) IsEntry() bool {
 	return n.entry
 }
 
+// Data returns the optional data which was stored with the
+// entry that terminates at this node. If no data was stored
+// (or if this node is not terminal for an entry) then the
+// empty string is returned.
+func (n *Node) Data() string {
+	return n.data
+}
+
+// Weight returns the optional weight (such as a frequency) which
+// was stored with the entry that terminates at this node. If no
+// weight was stored then zero is returned.
+func (n *Node) Weight() int {
+	return n.weight
+}
+
+// Keys returns the original keys (in the order in which they were
+// inserted) of the entry that terminates at this node, if the trie
+// is case-insensitive (see NewFoldedTrie). Otherwise it returns nil.
+func (n *Node) Keys() []string {
+	return n.keys
+}
+
 // IsLeaf may be called to determine if the current node is a
 // leaf. Note that a leaf is a terminal node but that a node
 // may also be terminal for an entry but not a leaf. In the
@@ -38,6 +65,7 @@ func (n *Node) makeChildNode(s string, entry bool) *Node {
 	} else {
 		n.children = append(n.children, &child)
 	}
+	n.index = n.index.added(n.children)
 	return &child
 }
 
@@ -45,9 +73,64 @@ func (n *Node) setChildNode(newNode *Node) bool {
 	//fmt.Printf("settingChildNode: %v\n", newNode)
 	n.childCount = 1
 	n.children = []*Node{newNode}
+	n.index = indexChildren(n.children)
 	return true
 }
 
+// split divides the node at byte offset i, moving the tail of
+// its value (along with its children and any entry) down into
+// a new - and only - child node.
+func (n *Node) split(i int) {
+	child := makeNode(n.value[i:], n.entry)
+	child.data = n.data
+	child.weight = n.weight
+	child.keys = n.keys
+	child.children = n.children
+	child.childCount = n.childCount
+	child.index = n.index
+	n.value = n.value[:i]
+	n.entry = false
+	n.data = ""
+	n.weight = 0
+	n.keys = nil
+	n.setChildNode(&child)
+}
+
+// merge is the reverse of split: it absorbs the node's only child,
+// appending the child's value and taking on its children and entry.
+func (n *Node) merge() {
+	child := n.children[0]
+	n.value += child.value
+	n.entry = child.entry
+	n.data = child.data
+	n.weight = child.weight
+	n.keys = child.keys
+	n.children = child.children
+	n.childCount = child.childCount
+	n.index = child.index
+}
+
+// child returns the child whose value begins with the first rune
+// of s, along with its position, or nil.
+func (n *Node) child(s string) (int, *Node) {
+
+	if s == "" {
+		return -1, nil
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	i := n.index.find(n.children, r)
+	if i < 0 {
+		return -1, nil
+	}
+	return i, n.children[i]
+}
+
+func (n *Node) removeChild(i int) {
+	n.children = append(n.children[:i], n.children[i+1:]...)
+	n.childCount = len(n.children)
+	n.index = indexChildren(n.children)
+}
+
 func makeNode(s string, isEntry bool) Node {
 	//fmt.Printf("makingNode: %s\n", s)
 	return Node{value: s, childCount: 0, entry: isEntry}
@@ -0,0 +1,191 @@
+package trie
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// PatriciaKey is the set of fixed-width keys a Patricia trie can
+// hold. Keys are compared bit by bit from the most significant bit
+// (of the first byte, for arrays), so they are ordered numerically
+// (or, for arrays, as by byte comparison).
+type PatriciaKey interface {
+	uint32 | uint64 | [16]byte
+}
+
+// Patricia is a binary PATRICIA trie, which - unlike Trie, which
+// branches on runes - branches on single bits of fixed-width keys.
+// Each internal node records the index of the first bit at which the
+// keys below it differ, and only that bit is tested on the way down:
+// the bits between it and the bit tested by its parent (which all
+// keys below it share) are skipped. So there are exactly n-1
+// internal nodes for n keys, and a lookup tests at most one bit per
+// level before comparing the whole key once, at the leaf.
+type Patricia[K PatriciaKey, V any] struct {
+	root  *patriciaNode[K, V]
+	count int
+}
+
+// patriciaNode is either a leaf (with no children) holding a key and
+// its value, or an internal node which tests bit.
+type patriciaNode[K PatriciaKey, V any] struct {
+	bit   int
+	child [2]*patriciaNode[K, V]
+	key   K
+	value V
+}
+
+func (n *patriciaNode[K, V]) isLeaf() bool {
+	return n.child[0] == nil
+}
+
+// NewPatricia returns an empty Patricia trie.
+func NewPatricia[K PatriciaKey, V any]() *Patricia[K, V] {
+	return &Patricia[K, V]{}
+}
+
+// patriciaBit returns bit i of k, counting from the most significant.
+func patriciaBit[K PatriciaKey](k K, i int) int {
+
+	switch k := any(k).(type) {
+	case uint32:
+		return int(k>>(31-i)) & 1
+	case uint64:
+		return int(k>>(63-i)) & 1
+	case [16]byte:
+		return int(k[i/8]>>(7-i%8)) & 1
+	}
+	panic("unreachable")
+}
+
+// patriciaDiff returns the index of the first bit at which a and b
+// differ, or -1 if they are equal.
+func patriciaDiff[K PatriciaKey](a K, b K) int {
+
+	if a == b {
+		return -1
+	}
+	switch a := any(a).(type) {
+	case uint32:
+		return bits.LeadingZeros32(a ^ any(b).(uint32))
+	case uint64:
+		return bits.LeadingZeros64(a ^ any(b).(uint64))
+	case [16]byte:
+		b := any(b).([16]byte)
+		for i := range a {
+			if a[i] != b[i] {
+				return 8*i + bits.LeadingZeros8(a[i]^b[i])
+			}
+		}
+	}
+	panic("unreachable")
+}
+
+// Count returns the number of keys in the trie.
+func (p *Patricia[K, V]) Count() int {
+	return p.count
+}
+
+// leaf follows the bits of k down to a leaf, which holds the only key
+// which can be k (but may not be).
+func (p *Patricia[K, V]) leaf(k K) *patriciaNode[K, V] {
+
+	n := p.root
+	for !n.isLeaf() {
+		n = n.child[patriciaBit(k, n.bit)]
+	}
+	return n
+}
+
+// Get returns the value for k, if it is in the trie.
+func (p *Patricia[K, V]) Get(k K) (V, bool) {
+
+	if p.root != nil {
+		if n := p.leaf(k); n.key == k {
+			return n.value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Insert sets the value for k, returning false if k was already in
+// the trie (in which case its value is replaced).
+func (p *Patricia[K, V]) Insert(k K, v V) bool {
+
+	if p.root == nil {
+		p.root = &patriciaNode[K, V]{key: k, value: v}
+		p.count++
+		return true
+	}
+
+	// The closest key already in the trie shares the longest prefix
+	// with k, so k branches off at the first bit where they differ
+	closest := p.leaf(k)
+	d := patriciaDiff(k, closest.key)
+	if d < 0 {
+		closest.value = v
+		return false
+	}
+
+	// The new internal node goes above the first node which tests a
+	// later bit (or is a leaf)
+	link := &p.root
+	for !(*link).isLeaf() && (*link).bit < d {
+		link = &(*link).child[patriciaBit(k, (*link).bit)]
+	}
+	branch := &patriciaNode[K, V]{bit: d}
+	b := patriciaBit(k, d)
+	branch.child[b] = &patriciaNode[K, V]{key: k, value: v}
+	branch.child[1-b] = *link
+	*link = branch
+	p.count++
+	return true
+}
+
+// Delete removes k from the trie, returning false if it was not
+// there.
+func (p *Patricia[K, V]) Delete(k K) bool {
+
+	if p.root == nil {
+		return false
+	}
+
+	// The parent of the leaf is replaced by the leaf's sibling
+	var parent **patriciaNode[K, V]
+	link := &p.root
+	for !(*link).isLeaf() {
+		parent = link
+		link = &(*link).child[patriciaBit(k, (*link).bit)]
+	}
+	if (*link).key != k {
+		return false
+	}
+	if parent == nil {
+		p.root = nil
+	} else if (*parent).child[0] == *link {
+		*parent = (*parent).child[1]
+	} else {
+		*parent = (*parent).child[0]
+	}
+	p.count--
+	return true
+}
+
+// All returns every key in the trie with its value, in key order.
+func (p *Patricia[K, V]) All() iter.Seq2[K, V] {
+
+	return func(yield func(K, V) bool) {
+		if p.root != nil {
+			p.root.visit(yield)
+		}
+	}
+}
+
+func (n *patriciaNode[K, V]) visit(yield func(K, V) bool) bool {
+
+	if n.isLeaf() {
+		return yield(n.key, n.value)
+	}
+	return n.child[0].visit(yield) && n.child[1].visit(yield)
+}
@@ -0,0 +1,225 @@
+package trie
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// checkPatricia checks that the bits tested increase down every path,
+// and that every key below a node agrees with the branch taken to it
+// and with the other keys there on every bit before the one tested.
+func checkPatricia[K PatriciaKey, V any](t *testing.T, name string, p *Patricia[K, V]) {
+
+	t.Helper()
+	leaves := 0
+	var visit func(n *patriciaNode[K, V], above int) []K
+	visit = func(n *patriciaNode[K, V], above int) []K {
+		if n.isLeaf() {
+			leaves++
+			return []K{n.key}
+		}
+		if n.bit <= above {
+			t.Errorf("test '%s': bit %d tested below bit %d", name, n.bit, above)
+		}
+		var keys []K
+		for b, c := range n.child {
+			for _, k := range visit(c, n.bit) {
+				if patriciaBit(k, n.bit) != b {
+					t.Errorf("test '%s': key %v is on the wrong side of bit %d", name, k, n.bit)
+				}
+				keys = append(keys, k)
+			}
+		}
+		for _, k := range keys {
+			if d := patriciaDiff(k, keys[0]); d >= 0 && d < n.bit {
+				t.Errorf("test '%s': keys %v and %v differ above bit %d", name, k, keys[0], n.bit)
+			}
+		}
+		return keys
+	}
+	if p.root != nil {
+		visit(p.root, -1)
+	}
+	if leaves != p.Count() {
+		t.Errorf("test '%s': expected %d leaves, but there were %d", name, p.Count(), leaves)
+	}
+}
+
+func TestPatricia(t *testing.T) {
+
+	p := NewPatricia[uint32, string]()
+	insertTests := []struct {
+		key      uint32
+		value    string
+		inserted bool
+	}{
+		{key: 0x0a000001, value: "a", inserted: true},
+		{key: 0x0a000002, value: "b", inserted: true},
+		{key: 0xc0a80001, value: "c", inserted: true},
+		{key: 0, value: "zero", inserted: true},
+		{key: 0xffffffff, value: "max", inserted: true},
+		{key: 0x0a000003, value: "d", inserted: true},
+		{key: 0x0a000002, value: "B", inserted: false},
+	}
+
+	for _, test := range insertTests {
+		if inserted := p.Insert(test.key, test.value); inserted != test.inserted {
+			t.Errorf("insert %#x: expected inserted to be %t", test.key, test.inserted)
+		}
+		checkPatricia(t, "insert", p)
+	}
+	if p.Count() != 6 {
+		t.Errorf("expected count to be 6, but was %d", p.Count())
+	}
+
+	getTests := []struct {
+		key   uint32
+		value string
+		found bool
+	}{
+		{key: 0x0a000001, value: "a", found: true},
+		{key: 0x0a000002, value: "B", found: true},
+		{key: 0, value: "zero", found: true},
+		{key: 0xffffffff, value: "max", found: true},
+		{key: 0x0a000004, found: false},
+		{key: 0xc0a80000, found: false},
+		{key: 1, found: false},
+	}
+
+	for _, test := range getTests {
+		value, found := p.Get(test.key)
+		if found != test.found || value != test.value {
+			t.Errorf("get %#x: expected (%q, %t), but was (%q, %t)", test.key, test.value, test.found, value, found)
+		}
+	}
+
+	var keys []uint32
+	for k := range p.All() {
+		keys = append(keys, k)
+	}
+	expected := []uint32{0, 0x0a000001, 0x0a000002, 0x0a000003, 0xc0a80001, 0xffffffff}
+	if !slices.Equal(keys, expected) {
+		t.Errorf("expected keys %x, but was %x", expected, keys)
+	}
+
+	deleteTests := []struct {
+		key     uint32
+		deleted bool
+	}{
+		{key: 0x0a000002, deleted: true},
+		{key: 0x0a000002, deleted: false},
+		{key: 0x0a000004, deleted: false},
+		{key: 0, deleted: true},
+		{key: 0x0a000001, deleted: true},
+		{key: 0x0a000003, deleted: true},
+		{key: 0xc0a80001, deleted: true},
+		{key: 0xffffffff, deleted: true},
+		{key: 0xffffffff, deleted: false},
+	}
+
+	for _, test := range deleteTests {
+		if deleted := p.Delete(test.key); deleted != test.deleted {
+			t.Errorf("delete %#x: expected deleted to be %t", test.key, test.deleted)
+		}
+		checkPatricia(t, "delete", p)
+	}
+	if p.Count() != 0 || p.root != nil {
+		t.Errorf("expected an empty trie")
+	}
+}
+
+func TestPatriciaRandom(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+	p := NewPatricia[uint64, int]()
+	expected := map[uint64]int{}
+	for i := 0; i < 2000; i++ {
+		// Narrow the keys at times, so that some are reused
+		k := r.Uint64()
+		if i%3 == 0 {
+			k &= 0xff
+		}
+		if r.Intn(4) == 0 {
+			for old := range expected {
+				if !p.Delete(old) {
+					t.Fatalf("expected to delete %#x", old)
+				}
+				delete(expected, old)
+				break
+			}
+		}
+		_, exists := expected[k]
+		if p.Insert(k, i) == exists {
+			t.Fatalf("insert %#x: expected inserted to be %t", k, !exists)
+		}
+		expected[k] = i
+	}
+	checkPatricia(t, "random", p)
+
+	if p.Count() != len(expected) {
+		t.Errorf("expected count to be %d, but was %d", len(expected), p.Count())
+	}
+	for k, v := range expected {
+		if got, ok := p.Get(k); !ok || got != v {
+			t.Fatalf("get %#x: expected %d, but was (%d, %t)", k, v, got, ok)
+		}
+	}
+	var keys []uint64
+	for k := range p.All() {
+		keys = append(keys, k)
+	}
+	if !slices.IsSorted(keys) || len(keys) != len(expected) {
+		t.Errorf("expected %d keys in order", len(expected))
+	}
+}
+
+func TestPatriciaArray(t *testing.T) {
+
+	key := func(last byte, first byte) [16]byte {
+		var k [16]byte
+		k[0], k[15] = first, last
+		return k
+	}
+
+	p := NewPatricia[[16]byte, bool]()
+	keys := [][16]byte{key(1, 0x20), key(2, 0x20), key(1, 0), key(0x80, 0xfe), key(0, 0)}
+	for _, k := range keys {
+		p.Insert(k, true)
+	}
+	checkPatricia(t, "array", p)
+
+	for _, k := range keys {
+		if _, ok := p.Get(k); !ok {
+			t.Errorf("expected to find %x", k)
+		}
+	}
+	if _, ok := p.Get(key(3, 0x20)); ok {
+		t.Errorf("expected not to find %x", key(3, 0x20))
+	}
+
+	var found [][16]byte
+	for k := range p.All() {
+		found = append(found, k)
+	}
+	slices.SortFunc(keys, func(a, b [16]byte) int { return slices.Compare(a[:], b[:]) })
+	if !slices.Equal(found, keys) {
+		t.Errorf("expected keys %x, but was %x", keys, found)
+	}
+}
+
+func BenchmarkPatriciaGet(b *testing.B) {
+
+	r := rand.New(rand.NewSource(1))
+	p := NewPatricia[uint64, int]()
+	keys := make([]uint64, 10000)
+	for i := range keys {
+		keys[i] = r.Uint64()
+		p.Insert(keys[i], i)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, benchmarkFound = p.Get(keys[i%len(keys)])
+	}
+}
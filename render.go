@@ -0,0 +1,56 @@
+package trie
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Render writes the trie to w as an indented tree, in the manner
+// of the diagrams in the README. Each line holds the value of a
+// node, with an asterisk marking those nodes which are entries:
+//
+//	s
+//	└── low*
+//	    ├── er*
+//	    └── ly*
+func (t *Trie) Render(w io.Writer) error {
+
+	t = t.tree()
+	bw := bufio.NewWriter(w)
+	for _, c := range t.child {
+		bw.WriteString(c.value)
+		if c.entry {
+			bw.WriteByte('*')
+		}
+		bw.WriteByte('\n')
+		renderChildren(bw, c, "")
+	}
+	return bw.Flush()
+}
+
+// String returns the trie rendered as by Render.
+func (t *Trie) String() string {
+
+	var sb strings.Builder
+	t.Render(&sb)
+	return sb.String()
+}
+
+func renderChildren(w *bufio.Writer, n *Node, indent string) {
+
+	for i, c := range n.children {
+		branch, next := "├── ", "│   "
+		if i == len(n.children)-1 {
+			branch, next = "└── ", "    "
+		}
+		w.WriteString(indent)
+		w.WriteString(branch)
+		w.WriteString(c.value)
+		if c.entry {
+			w.WriteByte('*')
+		}
+		w.WriteByte('\n')
+		renderChildren(w, c, indent+next)
+	}
+}
@@ -0,0 +1,53 @@
+package trie
+
+import "testing"
+
+func TestString(t *testing.T) {
+
+	stringTests := []struct {
+		name     string
+		trie     Trie
+		expected string
+	}{
+		{
+			name:     "empty trie",
+			trie:     getTrie(0, 'r'),
+			expected: "",
+		},
+		{
+			name:     "'s' trie with three elements",
+			trie:     getTrie(3, 's'),
+			expected: "s\n└── low*\n    ├── er*\n    └── ly*\n",
+		},
+		{
+			name: "'r' trie with seven elements",
+			trie: getTrie(7, 'r'),
+			expected: `r
+├── om
+│   ├── an
+│   │   ├── e*
+│   │   └── us*
+│   └── ulus*
+└── ub
+    ├── e
+    │   ├── ns*
+    │   └── r*
+    └── ic
+        ├── on*
+        └── undus*
+`,
+		},
+		{
+			name:     "chinese trie with two elements",
+			trie:     getStringTrie(2, "大"),
+			expected: "大\n├── 蒜*\n└── 豆*\n",
+		},
+	}
+
+	for _, test := range stringTests {
+		checkValid(t, test.name, &test.trie)
+		if s := test.trie.String(); s != test.expected {
+			t.Errorf("test '%s': expected\n%s\nbut was\n%s", test.name, test.expected, s)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package trie
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrInvalidRoute is returned for a route pattern which is not
+	// well-formed.
+	ErrInvalidRoute = errors.New("trie: invalid route")
+
+	// ErrRouteConflict is returned for a route which overlaps one
+	// already added in a way that precedence cannot settle.
+	ErrRouteConflict = errors.New("trie: conflicting route")
+)
+
+// Router is an http.Handler which dispatches requests by path, using
+// a trie of Nodes whose edges hold the static text of the routes.
+// A segment of a route pattern (the part between the slashes) may be:
+//
+//   - static, such as "users", matching only itself
+//   - a parameter, such as ":id", matching any non-empty segment
+//   - a catch-all, such as "*path", matching the rest of the path
+//     (including any slashes, or none) - so it must come last
+//
+// Parameters and catch-alls hang off the nodes which end at the
+// slash before them, and the text following a parameter starts a
+// trie of its own.
+//
+// Where more than one route matches, static segments take precedence
+// over parameters, which take precedence over catch-alls, segment by
+// segment from the left. So "/users/new" is preferred to "/users/:id"
+// for "/users/new", but "/users/:id/edit" still matches
+// "/users/new/edit" if there is no "/users/new/edit". A route only
+// matches if it has a handler for the request's method, so a PUT to
+// "/users/new" also goes to "/users/:id" if only that has a PUT.
+//
+// The values of parameters and catch-alls are set on the request, to
+// be read with Request.PathValue (a catch-all's value does not have a
+// leading slash).
+type Router struct {
+	root   Node
+	points map[*Node]*routePoint
+}
+
+// routePoint holds what follows a node of the router's trie: the
+// handlers (by method) of the routes which end there, and any
+// parameter or catch-all which comes next.
+type routePoint struct {
+	param     *Node // the root of the text following the parameter
+	paramName string
+	catchAll  *routePoint
+	catchName string
+	handlers  map[string]http.Handler
+	pattern   string // the pattern of the routes which end here
+}
+
+// NewRouter returns a Router with no routes.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle adds a route, so that requests with the given method whose
+// paths match pattern are served by h. An error is returned if the
+// pattern is not well-formed, or if it conflicts with an existing
+// route: one with the same method and the same segments (whatever
+// its parameters are called), or with a different name for a
+// parameter or catch-all in the same place.
+func (r *Router) Handle(method string, pattern string, h http.Handler) error {
+
+	if method == "" || h == nil || !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("%w: %s %q", ErrInvalidRoute, method, pattern)
+	}
+
+	// Check the whole pattern before changing the trie
+	segments := strings.Split(pattern[1:], "/")
+	names := map[string]bool{}
+	for i, s := range segments {
+		if s == "" || (s[0] != ':' && s[0] != '*') {
+			continue
+		}
+		if len(s) == 1 || (s[0] == '*' && i != len(segments)-1) || names[s[1:]] {
+			return fmt.Errorf("%w: %s %q", ErrInvalidRoute, method, pattern)
+		}
+		names[s[1:]] = true
+	}
+	n, text := &r.root, ""
+	for _, s := range segments {
+		if s == "" || (s[0] != ':' && s[0] != '*') {
+			text += "/" + s
+			continue
+		}
+		if n = textNode(n, text+"/"); n == nil || r.points[n] == nil {
+			break
+		}
+		p := r.points[n]
+		if s[0] == ':' {
+			if p.param != nil && p.paramName != s[1:] {
+				return fmt.Errorf("%w: %q has :%s where another route has :%s", ErrRouteConflict, pattern, s[1:], p.paramName)
+			}
+			n, text = p.param, ""
+			if n == nil {
+				break
+			}
+		} else if p.catchAll != nil && p.catchName != s[1:] {
+			return fmt.Errorf("%w: %q has *%s where another route has *%s", ErrRouteConflict, pattern, s[1:], p.catchName)
+		}
+	}
+
+	var p *routePoint
+	n, text = &r.root, ""
+	for _, s := range segments {
+		switch {
+		case strings.HasPrefix(s, ":"):
+			p = r.point(r.addText(n, text+"/"))
+			if p.param == nil {
+				p.param, p.paramName = &Node{}, s[1:]
+			}
+			n, text = p.param, ""
+		case strings.HasPrefix(s, "*"):
+			// The node before the slash is where a path with no more
+			// to it ends, so it must be found too
+			p = r.point(r.addText(r.addText(n, text), "/"))
+			if p.catchAll == nil {
+				p.catchAll, p.catchName = &routePoint{}, s[1:]
+			}
+			p, n = p.catchAll, nil
+		default:
+			text += "/" + s
+		}
+	}
+	if n != nil {
+		p = r.point(r.addText(n, text))
+	}
+
+	if _, ok := p.handlers[method]; ok {
+		return fmt.Errorf("%w: %s %q is already routed as %q", ErrRouteConflict, method, pattern, p.pattern)
+	}
+	if p.handlers == nil {
+		p.handlers = map[string]http.Handler{}
+		p.pattern = pattern
+	}
+	p.handlers[method] = h
+	return nil
+}
+
+// HandleFunc adds a route served by the function h, as for Handle.
+func (r *Router) HandleFunc(method string, pattern string, h func(http.ResponseWriter, *http.Request)) error {
+	return r.Handle(method, pattern, http.HandlerFunc(h))
+}
+
+// point returns the routePoint of n, making it if need be. Nodes
+// with one are marked as entries.
+func (r *Router) point(n *Node) *routePoint {
+
+	p, ok := r.points[n]
+	if !ok {
+		if r.points == nil {
+			r.points = map[*Node]*routePoint{}
+		}
+		p = &routePoint{}
+		r.points[n] = p
+		n.entry = true
+	}
+	return p
+}
+
+// addText returns the node reached from n by s, adding or splitting
+// nodes if need be.
+func (r *Router) addText(n *Node, s string) *Node {
+
+	for s != "" {
+		_, c := n.child(s)
+		if c == nil {
+			return n.makeChildNode(s, false)
+		}
+		i := 0
+		for i < len(c.value) {
+			_, size := utf8.DecodeRuneInString(c.value[i:])
+			if i+size > len(s) || c.value[i:i+size] != s[i:i+size] {
+				break
+			}
+			i += size
+		}
+		if i < len(c.value) {
+			// The split moves the end of c, and so its routePoint, down
+			// into its new child
+			c.split(i)
+			if p, ok := r.points[c]; ok {
+				r.points[c.children[0]] = p
+				delete(r.points, c)
+			}
+		}
+		n, s = c, s[i:]
+	}
+	return n
+}
+
+// textNode returns the node reached from n by exactly s, or nil.
+func textNode(n *Node, s string) *Node {
+
+	for s != "" {
+		_, c := n.child(s)
+		if c == nil || !strings.HasPrefix(s, c.value) {
+			return nil
+		}
+		n, s = c, s[len(c.value):]
+	}
+	return n
+}
+
+// routeValue is the value of a parameter or catch-all.
+type routeValue struct {
+	name  string
+	value string
+}
+
+// routeMatch is a search for the route which matches a path.
+type routeMatch struct {
+	method  string
+	values  []routeValue
+	allowed []string // the methods of routes which matched but for method
+}
+
+// handler returns the handler for the method being matched, noting
+// the route's methods if it has none.
+func (m *routeMatch) handler(p *routePoint) http.Handler {
+
+	if p == nil || p.handlers == nil {
+		return nil
+	}
+	h, ok := p.handlers[m.method]
+	if !ok && m.method == http.MethodHead {
+		h, ok = p.handlers[http.MethodGet]
+	}
+	if !ok {
+		for method := range p.handlers {
+			m.allowed = append(m.allowed, method)
+		}
+		return nil
+	}
+	return h
+}
+
+// match returns the handler of the route matching the rest of the
+// path from n, trying static text, the parameter and the catch-all
+// in order of precedence and backtracking if they fail.
+func (r *Router) match(n *Node, path string, m *routeMatch) http.Handler {
+
+	p := r.points[n]
+	if path == "" {
+		if h := m.handler(p); h != nil {
+			return h
+		}
+		if p != nil && p.catchAll != nil {
+			if h := r.matchValue(p.catchAll, p.catchName, "", m); h != nil {
+				return h
+			}
+		}
+		// A catch-all still matches without the slash before it
+		if _, c := n.child("/"); c != nil && c.value == "/" && r.points[c] != nil && r.points[c].catchAll != nil {
+			return r.matchValue(r.points[c].catchAll, r.points[c].catchName, "", m)
+		}
+		return nil
+	}
+
+	if _, c := n.child(path); c != nil && strings.HasPrefix(path, c.value) {
+		if h := r.match(c, path[len(c.value):], m); h != nil {
+			return h
+		}
+	}
+	if p == nil {
+		return nil
+	}
+	if segment, _, _ := strings.Cut(path, "/"); p.param != nil && segment != "" {
+		m.values = append(m.values, routeValue{p.paramName, segment})
+		if h := r.match(p.param, path[len(segment):], m); h != nil {
+			return h
+		}
+		m.values = m.values[:len(m.values)-1]
+	}
+	if p.catchAll != nil {
+		return r.matchValue(p.catchAll, p.catchName, path, m)
+	}
+	return nil
+}
+
+// matchValue returns the handler of the catch-all p, given value.
+func (r *Router) matchValue(p *routePoint, name string, value string, m *routeMatch) http.Handler {
+
+	h := m.handler(p)
+	if h != nil {
+		m.values = append(m.values, routeValue{name, value})
+	}
+	return h
+}
+
+// ServeHTTP dispatches the request to the handler of the route which
+// matches its path and method. If no route matches, it replies with
+// 404 Not Found; if routes match but not for the request's method,
+// it replies with 405 Method Not Allowed (listing their methods in
+// the Allow header).
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+
+	path := req.URL.Path
+	if !strings.HasPrefix(path, "/") {
+		http.NotFound(w, req)
+		return
+	}
+	m := routeMatch{method: req.Method}
+	h := r.match(&r.root, path, &m)
+	if h == nil && len(m.allowed) == 0 {
+		http.NotFound(w, req)
+		return
+	}
+	if h == nil {
+		slices.Sort(m.allowed)
+		w.Header().Set("Allow", strings.Join(slices.Compact(m.allowed), ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	for _, v := range m.values {
+		req.SetPathValue(v.name, v.value)
+	}
+	h.ServeHTTP(w, req)
+}
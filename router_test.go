@@ -0,0 +1,205 @@
+package trie
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// routeHandler replies with the route's name and the path values.
+func routeHandler(name string, values ...string) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, name)
+		for _, v := range values {
+			fmt.Fprintf(w, " %s=%s", v, r.PathValue(v))
+		}
+	})
+}
+
+func getRouter(t *testing.T) *Router {
+
+	r := NewRouter()
+	routes := []struct {
+		method  string
+		pattern string
+		h       http.Handler
+	}{
+		{http.MethodGet, "/", routeHandler("home")},
+		{http.MethodGet, "/users", routeHandler("list")},
+		{http.MethodPost, "/users", routeHandler("create")},
+		{http.MethodGet, "/users/new", routeHandler("new")},
+		{http.MethodGet, "/users/:id", routeHandler("show", "id")},
+		{http.MethodPut, "/users/:id", routeHandler("update", "id")},
+		{http.MethodGet, "/users/:id/edit", routeHandler("edit", "id")},
+		{http.MethodGet, "/users/:id/posts/:post", routeHandler("post", "id", "post")},
+		{http.MethodGet, "/files/*path", routeHandler("files", "path")},
+		{http.MethodGet, "/files/readme", routeHandler("readme")},
+		{http.MethodGet, "/*rest", routeHandler("fallback", "rest")},
+	}
+	for _, route := range routes {
+		if err := r.Handle(route.method, route.pattern, route.h); err != nil {
+			t.Fatalf("route %s %s: unexpected error: %v", route.method, route.pattern, err)
+		}
+	}
+	return r
+}
+
+func TestRouter(t *testing.T) {
+
+	r := getRouter(t)
+
+	routeTests := []struct {
+		method   string
+		path     string
+		status   int
+		expected string
+	}{
+		{method: "GET", path: "/", status: 200, expected: "home"},
+		{method: "GET", path: "/users", status: 200, expected: "list"},
+		{method: "POST", path: "/users", status: 200, expected: "create"},
+		{method: "GET", path: "/users/new", status: 200, expected: "new"},
+		{method: "GET", path: "/users/42", status: 200, expected: "show id=42"},
+		{method: "GET", path: "/users/new/edit", status: 200, expected: "edit id=new"},
+		{method: "GET", path: "/users/42/posts/7", status: 200, expected: "post id=42 post=7"},
+		{method: "GET", path: "/files/a/b.txt", status: 200, expected: "files path=a/b.txt"},
+		{method: "GET", path: "/files/readme", status: 200, expected: "readme"},
+		{method: "GET", path: "/files/", status: 200, expected: "files path="},
+		{method: "GET", path: "/files", status: 200, expected: "files path="},
+		{method: "GET", path: "/users/42/other", status: 200, expected: "fallback rest=users/42/other"},
+		{method: "GET", path: "/users/", status: 200, expected: "fallback rest=users/"},
+		{method: "HEAD", path: "/users/42", status: 200, expected: ""},
+		{method: "PUT", path: "/users/42", status: 200, expected: "update id=42"},
+		{method: "PUT", path: "/users/new", status: 200, expected: "update id=new"},
+		{method: "PUT", path: "/users/new/edit", status: 405, expected: "Method Not Allowed\n"},
+		{method: "DELETE", path: "/users", status: 405, expected: "Method Not Allowed\n"},
+	}
+
+	for _, test := range routeTests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.status {
+			t.Errorf("%s %s: expected status %d, but was %d", test.method, test.path, test.status, w.Code)
+		}
+		if test.method != "HEAD" && w.Body.String() != test.expected {
+			t.Errorf("%s %s: expected %q, but was %q", test.method, test.path, test.expected, w.Body.String())
+		}
+	}
+
+	// Every route which matches the path counts towards Allow
+	allowTests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/users", expected: "GET, POST"},
+		{path: "/users/new", expected: "GET, PUT"},
+		{path: "/files/readme", expected: "GET"},
+	}
+
+	for _, test := range allowTests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("DELETE", test.path, nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("DELETE %s: expected status 405, but was %d", test.path, w.Code)
+		}
+		if allow := w.Header().Get("Allow"); allow != test.expected {
+			t.Errorf("DELETE %s: expected Allow to be '%s', but was '%s'", test.path, test.expected, allow)
+		}
+	}
+}
+
+func TestRouterSharedText(t *testing.T) {
+
+	// Each route splits the edges of those before it, which must
+	// keep their handlers (and parameters)
+	r := NewRouter()
+	for _, pattern := range []string{"/users/:id", "/users", "/user", "/us", "/u/:id", "/大豆", "/大米"} {
+		if err := r.Handle(http.MethodGet, pattern, routeHandler(pattern, "id")); err != nil {
+			t.Fatalf("route %s: unexpected error: %v", pattern, err)
+		}
+	}
+
+	routeTests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/users/42", expected: "/users/:id id=42"},
+		{path: "/users", expected: "/users id="},
+		{path: "/user", expected: "/user id="},
+		{path: "/us", expected: "/us id="},
+		{path: "/u/7", expected: "/u/:id id=7"},
+		{path: "/大豆", expected: "/大豆 id="},
+		{path: "/大米", expected: "/大米 id="},
+	}
+
+	for _, test := range routeTests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		if w.Body.String() != test.expected {
+			t.Errorf("GET %s: expected %q, but was %q", test.path, test.expected, w.Body.String())
+		}
+	}
+	for _, path := range []string{"/use", "/u", "/大", "/users/42/x"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected status 404, but was %d", path, w.Code)
+		}
+	}
+}
+
+func TestRouterNotFound(t *testing.T) {
+
+	r := NewRouter()
+	r.HandleFunc(http.MethodGet, "/users/:id", func(w http.ResponseWriter, req *http.Request) {})
+
+	for _, path := range []string{"/users", "/users/", "/users/42/edit", "/other"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected status 404, but was %d", path, w.Code)
+		}
+	}
+}
+
+func TestRouterConflicts(t *testing.T) {
+
+	conflictTests := []struct {
+		name    string
+		method  string
+		pattern string
+		err     error
+	}{
+		{name: "duplicate", method: "GET", pattern: "/users/:id", err: ErrRouteConflict},
+		{name: "renamed parameter", method: "GET", pattern: "/users/:name", err: ErrRouteConflict},
+		{name: "renamed parameter below", method: "GET", pattern: "/users/:user/likes", err: ErrRouteConflict},
+		{name: "renamed catch-all", method: "GET", pattern: "/files/*file", err: ErrRouteConflict},
+		{name: "other method", method: "DELETE", pattern: "/users/:id", err: nil},
+		{name: "static beside parameter", method: "GET", pattern: "/users/me", err: nil},
+		{name: "parameter beside catch-all", method: "GET", pattern: "/files/:name/info", err: nil},
+		{name: "no slash", method: "GET", pattern: "users", err: ErrInvalidRoute},
+		{name: "no method", method: "", pattern: "/about", err: ErrInvalidRoute},
+		{name: "unnamed parameter", method: "GET", pattern: "/about/:", err: ErrInvalidRoute},
+		{name: "catch-all not last", method: "GET", pattern: "/about/*rest/more", err: ErrInvalidRoute},
+		{name: "repeated name", method: "GET", pattern: "/about/:id/:id", err: ErrInvalidRoute},
+	}
+
+	for _, test := range conflictTests {
+		r := getRouter(t)
+		err := r.Handle(test.method, test.pattern, routeHandler(test.name))
+		if !errors.Is(err, test.err) {
+			t.Errorf("test '%s': expected error %v, but was %v", test.name, test.err, err)
+		}
+	}
+
+	// A rejected route must leave the router unchanged
+	r := getRouter(t)
+	r.Handle("GET", "/admin/:id/*rest/more", routeHandler("bad", "id"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/1/x/more", nil))
+	if w.Body.String() != "fallback rest=admin/1/x/more" {
+		t.Errorf("expected the rejected route not to match, but was %q", w.Body.String())
+	}
+}
@@ -0,0 +1,110 @@
+package trie
+
+import (
+	"math"
+	"unicode/utf8"
+)
+
+// Segmenter splits unspaced text (such as Chinese or Japanese) into
+// the entries of a trie. Any rune which does not begin an entry is
+// returned as a segment on its own, so the segments always join up
+// to form the original text.
+type Segmenter struct {
+	trie  *Trie
+	total float64
+
+	// MaxProbability selects segmentation by dynamic programming,
+	// choosing the sequence of segments with the highest product of
+	// probabilities (where the probability of an entry is its weight
+	// as a share of the total weight of all entries). Otherwise the
+	// text is segmented greedily, by repeatedly taking the longest
+	// entry which is a prefix of the rest of the text.
+	MaxProbability bool
+}
+
+// NewSegmenter returns a greedy Segmenter for the entries of t. The
+// weights of the entries are totalled at this point, so entries
+// inserted afterwards will be found but will skew the probabilities
+// used by MaxProbability segmentation.
+func NewSegmenter(t *Trie) *Segmenter {
+
+	s := &Segmenter{trie: t}
+	t.Walk(func(_ string, n *Node) bool {
+		s.total += float64(segmentWeight(n))
+		return true
+	})
+	return s
+}
+
+// Segment splits text into segments as described for Segmenter.
+func (s *Segmenter) Segment(text string) []string {
+
+	if s.MaxProbability {
+		return s.segmentDP(text)
+	}
+
+	var segments []string
+	for text != "" {
+		end := 0
+		s.trie.walkPrefixes(text, func(e int, _ *Node) {
+			end = e
+		})
+		if end == 0 {
+			_, end = utf8.DecodeRuneInString(text)
+		}
+		segments = append(segments, text[:end])
+		text = text[end:]
+	}
+	return segments
+}
+
+func (s *Segmenter) segmentDP(text string) []string {
+
+	// best[i] is the highest log probability of any segmentation of
+	// text[:i], which ends with the segment starting at from[i]
+	best := make([]float64, len(text)+1)
+	from := make([]int, len(text)+1)
+	for i := 1; i <= len(text); i++ {
+		best[i] = math.Inf(-1)
+	}
+
+	// An unknown rune is less likely than the rarest entry
+	total := math.Max(s.total, 1)
+	unknown := math.Log(0.5 / total)
+
+	for i := 0; i < len(text); i++ {
+		if math.IsInf(best[i], -1) {
+			// Not a rune boundary
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		if p := best[i] + unknown; p > best[i+size] {
+			best[i+size], from[i+size] = p, i
+		}
+		s.trie.walkPrefixes(text[i:], func(e int, n *Node) {
+			p := best[i] + math.Log(float64(segmentWeight(n))/total)
+			if p > best[i+e] {
+				best[i+e], from[i+e] = p, i
+			}
+		})
+	}
+
+	var segments []string
+	for end := len(text); end > 0; end = from[end] {
+		segments = append(segments, text[from[end]:end])
+	}
+	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+		segments[i], segments[j] = segments[j], segments[i]
+	}
+	return segments
+}
+
+// segmentWeight treats entries without a (positive) weight as having
+// been seen once.
+func segmentWeight(n *Node) int {
+
+	if n.weight < 1 {
+		return 1
+	}
+	return n.weight
+}
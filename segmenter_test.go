@@ -0,0 +1,75 @@
+package trie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSegment(t *testing.T) {
+
+	trie := NewTrie()
+	for _, w := range []struct {
+		word   string
+		weight int
+	}{
+		{"研究", 10}, {"研究生", 2}, {"生命", 10}, {"起源", 5}, {"大豆", 3}, {"大豆油", 1},
+	} {
+		trie.InsertWeight(w.word, w.weight)
+	}
+	checkValid(t, "segmenter", &trie)
+
+	segmentTests := []struct {
+		name           string
+		text           string
+		maxProbability bool
+		expected       []string
+	}{
+		{
+			name:     "empty text",
+			text:     "",
+			expected: nil,
+		},
+		{
+			name:     "greedy longest match",
+			text:     "研究生命起源",
+			expected: []string{"研究生", "命", "起源"},
+		},
+		{
+			name:           "maximum probability",
+			text:           "研究生命起源",
+			maxProbability: true,
+			expected:       []string{"研究", "生命", "起源"},
+		},
+		{
+			name:     "unknown runes fall back to single runes",
+			text:     "买大豆油了",
+			expected: []string{"买", "大豆油", "了"},
+		},
+		{
+			name:           "unknown runes fall back to single runes by probability",
+			text:           "买大豆油了",
+			maxProbability: true,
+			expected:       []string{"买", "大豆油", "了"},
+		},
+		{
+			name:     "no entries at all",
+			text:     "a大b",
+			expected: []string{"a", "大", "b"},
+		},
+		{
+			name:           "no entries at all by probability",
+			text:           "a大b",
+			maxProbability: true,
+			expected:       []string{"a", "大", "b"},
+		},
+	}
+
+	for _, test := range segmentTests {
+		segmenter := NewSegmenter(&trie)
+		segmenter.MaxProbability = test.maxProbability
+		segments := segmenter.Segment(test.text)
+		if !reflect.DeepEqual(segments, test.expected) {
+			t.Errorf("test '%s': expected %v, but was %v", test.name, test.expected, segments)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// The binary encoding is laid out as follows:
+//
+//	magic    4 bytes ("RDXT")
+//	version  1 byte
+//	length   8 bytes (big-endian length of the body)
+//	body     length bytes
+//	checksum 4 bytes (big-endian CRC-32 of magic, version, length & body)
+//
+// The body holds a flags byte for the trie (marking whether it is
+// case-insensitive), the entry count and the number of root nodes
+// (both as uvarints), followed by every node in pre-order. Each
+// node is encoded as a flags byte, the edge fragment (uvarint length
+// plus bytes), the optional data (likewise, only present if
+// flagged), the optional weight (varint, only present if flagged),
+// the optional original terms of a case-insensitive entry (a uvarint
+// count, then each term as for the edge, only present if flagged)
+// and finally the child count (uvarint) - after which come the
+// children.
+//
+// Version 1 had no weights (so no weight flag) and version 2 had no
+// case folding (so no flags byte for the trie, nor terms), and both
+// can still be decoded.
+const (
+	binaryMagic   = "RDXT"
+	binaryVersion = 3
+
+	binaryHeaderLen = len(binaryMagic) + 1 + 8
+)
+
+const (
+	flagEntry byte = 1 << iota
+	flagData
+	flagWeight
+	flagKeys
+)
+
+// The flags of the trie itself
+const (
+	flagFolded byte = 1 << iota
+)
+
+var (
+	// ErrBadMagic is returned when decoding input which is not a
+	// binary-encoded trie.
+	ErrBadMagic = errors.New("trie: bad magic number")
+
+	// ErrBadVersion is returned when decoding input which was
+	// written by an unsupported version of the encoder.
+	ErrBadVersion = errors.New("trie: unsupported encoding version")
+
+	// ErrChecksum is returned when the checksum of the decoded
+	// input does not match the one that was stored with it.
+	ErrChecksum = errors.New("trie: checksum mismatch")
+
+	// ErrCorrupt is returned when the decoded input is malformed.
+	ErrCorrupt = errors.New("trie: corrupt encoding")
+)
+
+// MarshalBinary encodes the trie into a versioned, checksummed
+// binary form. This can be reloaded with UnmarshalBinary, which
+// is much faster than re-inserting every entry.
+func (t *Trie) MarshalBinary() ([]byte, error) {
+
+	var buf bytes.Buffer
+	if _, err := t.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the contents of the trie with those
+// decoded from data, which must have come from MarshalBinary.
+func (t *Trie) UnmarshalBinary(data []byte) error {
+
+	r := bytes.NewReader(data)
+	if _, err := t.ReadFrom(r); err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return ErrCorrupt
+	}
+	return nil
+}
+
+// WriteTo writes the binary encoding of the trie to w. It
+// returns the number of bytes written.
+func (t *Trie) WriteTo(w io.Writer) (int64, error) {
+
+	t = t.tree()
+	var flags byte
+	if t.fold {
+		flags |= flagFolded
+	}
+	body := []byte{flags}
+	body = binary.AppendUvarint(body, uint64(t.count))
+	body = binary.AppendUvarint(body, uint64(len(t.child)))
+	for _, c := range t.child {
+		body = appendBinaryNode(body, c)
+	}
+
+	out := make([]byte, 0, binaryHeaderLen+len(body)+4)
+	out = append(out, binaryMagic...)
+	out = append(out, binaryVersion)
+	out = binary.BigEndian.AppendUint64(out, uint64(len(body)))
+	out = append(out, body...)
+	out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out))
+
+	n, err := w.Write(out)
+	return int64(n), err
+}
+
+// ReadFrom replaces the contents of the trie with the binary
+// encoding read from r. Exactly one encoded trie is consumed,
+// so it is safe to use on a stream holding other data after
+// the trie. It returns the number of bytes read.
+func (t *Trie) ReadFrom(r io.Reader) (int64, error) {
+
+	var read int64
+
+	header := make([]byte, binaryHeaderLen)
+	n, err := io.ReadFull(r, header)
+	read += int64(n)
+	if err != nil {
+		return read, err
+	}
+	if string(header[:len(binaryMagic)]) != binaryMagic {
+		return read, ErrBadMagic
+	}
+	version := header[len(binaryMagic)]
+	if version < 1 || version > binaryVersion {
+		return read, ErrBadVersion
+	}
+
+	// Read through a limit rather than allocating the stated
+	// length up front, so that a corrupt length cannot cause
+	// a huge allocation
+	length := binary.BigEndian.Uint64(header[len(binaryMagic)+1:])
+	if length > math.MaxInt64-4 {
+		return read, ErrCorrupt
+	}
+	rest, err := io.ReadAll(io.LimitReader(r, int64(length)+4))
+	read += int64(len(rest))
+	if err != nil {
+		return read, err
+	}
+	if uint64(len(rest)) != length+4 {
+		return read, io.ErrUnexpectedEOF
+	}
+
+	body := rest[:length]
+	crc := crc32.ChecksumIEEE(header)
+	crc = crc32.Update(crc, crc32.IEEETable, body)
+	if crc != binary.BigEndian.Uint32(rest[length:]) {
+		return read, ErrChecksum
+	}
+
+	decoded, err := decodeBinaryBody(body, version)
+	if err != nil {
+		return read, err
+	}
+	t.replace(decoded)
+	return read, nil
+}
+
+func appendBinaryNode(b []byte, n *Node) []byte {
+
+	var flags byte
+	if n.entry {
+		flags |= flagEntry
+	}
+	if n.data != "" {
+		flags |= flagData
+	}
+	if n.weight != 0 {
+		flags |= flagWeight
+	}
+	if len(n.keys) > 0 {
+		flags |= flagKeys
+	}
+	b = append(b, flags)
+	b = binary.AppendUvarint(b, uint64(len(n.value)))
+	b = append(b, n.value...)
+	if flags&flagData != 0 {
+		b = binary.AppendUvarint(b, uint64(len(n.data)))
+		b = append(b, n.data...)
+	}
+	if flags&flagWeight != 0 {
+		b = binary.AppendVarint(b, int64(n.weight))
+	}
+	if flags&flagKeys != 0 {
+		b = binary.AppendUvarint(b, uint64(len(n.keys)))
+		for _, k := range n.keys {
+			b = binary.AppendUvarint(b, uint64(len(k)))
+			b = append(b, k...)
+		}
+	}
+	b = binary.AppendUvarint(b, uint64(len(n.children)))
+	for _, c := range n.children {
+		b = appendBinaryNode(b, c)
+	}
+	return b
+}
+
+// binaryDecoder walks the body of a binary-encoded trie.
+type binaryDecoder struct {
+	buf     []byte
+	entries int
+	flags   byte // the node flags known to the version being decoded
+}
+
+func decodeBinaryBody(body []byte, version byte) (Trie, error) {
+
+	d := binaryDecoder{buf: body, flags: flagEntry | flagData | flagWeight | flagKeys}
+	switch version {
+	case 1:
+		d.flags = flagEntry | flagData
+	case 2:
+		d.flags = flagEntry | flagData | flagWeight
+	}
+
+	t := NewTrie()
+	if version >= 3 {
+		if len(d.buf) == 0 || d.buf[0]&^flagFolded != 0 {
+			return Trie{}, ErrCorrupt
+		}
+		t.fold = d.buf[0]&flagFolded != 0
+		d.buf = d.buf[1:]
+	}
+
+	count, err := d.uvarint()
+	if err != nil {
+		return Trie{}, err
+	}
+	roots, err := d.uvarint()
+	if err != nil {
+		return Trie{}, err
+	}
+
+	// Every node needs at least one byte, which bounds the
+	// number of nodes that can legitimately follow
+	if roots > uint64(len(d.buf)) {
+		return Trie{}, ErrCorrupt
+	}
+
+	for i := uint64(0); i < roots; i++ {
+		n, err := d.node()
+		if err != nil {
+			return Trie{}, err
+		}
+		t.child = append(t.child, n)
+	}
+	t.roots = indexChildren(t.child)
+	if len(d.buf) != 0 || uint64(d.entries) != count {
+		return Trie{}, ErrCorrupt
+	}
+	t.count = d.entries
+	if t.Validate() != nil {
+		return Trie{}, ErrCorrupt
+	}
+	return t, nil
+}
+
+func (d *binaryDecoder) uvarint() (uint64, error) {
+
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		return 0, ErrCorrupt
+	}
+	d.buf = d.buf[n:]
+	return v, nil
+}
+
+func (d *binaryDecoder) bytes() (string, error) {
+
+	l, err := d.uvarint()
+	if err != nil {
+		return "", err
+	}
+	if l > uint64(len(d.buf)) {
+		return "", ErrCorrupt
+	}
+	s := string(d.buf[:l])
+	d.buf = d.buf[l:]
+	return s, nil
+}
+
+func (d *binaryDecoder) node() (*Node, error) {
+
+	if len(d.buf) == 0 {
+		return nil, ErrCorrupt
+	}
+	flags := d.buf[0]
+	d.buf = d.buf[1:]
+	if flags&^d.flags != 0 {
+		return nil, ErrCorrupt
+	}
+
+	value, err := d.bytes()
+	if err != nil {
+		return nil, err
+	}
+	n := makeNode(value, flags&flagEntry != 0)
+	if n.entry {
+		d.entries++
+	}
+	if flags&flagData != 0 {
+		if n.data, err = d.bytes(); err != nil {
+			return nil, err
+		}
+	}
+	if flags&flagWeight != 0 {
+		w, l := binary.Varint(d.buf)
+		if l <= 0 || int64(int(w)) != w {
+			return nil, ErrCorrupt
+		}
+		n.weight = int(w)
+		d.buf = d.buf[l:]
+	}
+	if flags&flagKeys != 0 {
+		keys, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if keys > uint64(len(d.buf)) {
+			return nil, ErrCorrupt
+		}
+		for i := uint64(0); i < keys; i++ {
+			k, err := d.bytes()
+			if err != nil {
+				return nil, err
+			}
+			n.keys = append(n.keys, k)
+		}
+	}
+
+	children, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if children > uint64(len(d.buf)) {
+		return nil, ErrCorrupt
+	}
+	for i := uint64(0); i < children; i++ {
+		c, err := d.node()
+		if err != nil {
+			return nil, err
+		}
+		n.children = append(n.children, c)
+	}
+	n.childCount = len(n.children)
+	n.index = indexChildren(n.children)
+	return &n, nil
+}
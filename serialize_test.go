@@ -0,0 +1,275 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+
+	mixed := NewTrie()
+	for _, s := range []string{"romane", "romanus", "slow", "slower", "test", "toaster"} {
+		mixed.Insert(s)
+	}
+	mixed.InsertData("rubicon", "river")
+	mixed.InsertWeight("slowly", 9)
+
+	roundTripTests := []struct {
+		name  string
+		trie  Trie
+		found []string
+	}{
+		{
+			name:  "empty trie",
+			trie:  getTrie(0, 'r'),
+			found: []string{},
+		},
+		{
+			name:  "'r' trie with seven elements",
+			trie:  getTrie(7, 'r'),
+			found: []string{"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus"},
+		},
+		{
+			name:  "'s' trie with three elements",
+			trie:  getTrie(3, 's'),
+			found: []string{"slow", "slower", "slowly"},
+		},
+		{
+			name:  "chinese trie with two elements",
+			trie:  getStringTrie(2, "大"),
+			found: []string{"大蒜", "大豆"},
+		},
+		{
+			name:  "trie with several roots and data",
+			trie:  mixed,
+			found: []string{"romane", "romanus", "rubicon", "slow", "slower", "slowly", "test", "toaster"},
+		},
+	}
+
+	for _, test := range roundTripTests {
+		b, err := test.trie.MarshalBinary()
+		if err != nil {
+			t.Errorf("test '%s': unexpected marshal error: %v", test.name, err)
+			continue
+		}
+		decoded := NewTrie()
+		if err := decoded.UnmarshalBinary(b); err != nil {
+			t.Errorf("test '%s': unexpected unmarshal error: %v", test.name, err)
+			continue
+		}
+		checkValid(t, test.name, &test.trie)
+		checkValid(t, test.name, &decoded)
+		if decoded.Count() != test.trie.Count() {
+			t.Errorf("test '%s': expected count to be %d, but was %d", test.name, test.trie.Count(), decoded.Count())
+		}
+		for _, s := range test.found {
+			found, n := decoded.Find(s)
+			if !found {
+				t.Errorf("test '%s': expected to find '%s'", test.name, s)
+				continue
+			}
+			_, orig := test.trie.Find(s)
+			if n.IsEntry() != orig.IsEntry() || n.IsLeaf() != orig.IsLeaf() || n.Data() != orig.Data() || n.Weight() != orig.Weight() {
+				t.Errorf("test '%s': decoded node for '%s' differs from original", test.name, s)
+			}
+		}
+	}
+
+	_, n := mixed.Find("rubicon")
+	if n.Data() != "river" {
+		t.Errorf("expected data to be 'river', but was '%s'", n.Data())
+	}
+}
+
+func TestWriteToReadFrom(t *testing.T) {
+
+	trie := getTrie(5, 'r')
+
+	var buf bytes.Buffer
+	written, err := trie.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if written != int64(buf.Len()) {
+		t.Errorf("expected %d bytes written, but reported %d", buf.Len(), written)
+	}
+
+	// Anything following the encoding must be left unread
+	buf.WriteString("trailer")
+
+	decoded := NewTrie()
+	read, err := decoded.ReadFrom(&buf)
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if read != written {
+		t.Errorf("expected %d bytes read, but read %d", written, read)
+	}
+	if buf.String() != "trailer" {
+		t.Errorf("expected trailer to be left unread, but found '%s'", buf.String())
+	}
+	checkValid(t, "decoded", &decoded)
+	if found, _ := decoded.Find("ruber"); !found {
+		t.Errorf("expected to find 'ruber'")
+	}
+}
+
+// withVersion returns data with its version byte replaced (and its
+// checksum recomputed). Before version 3 there was no flags byte for
+// the trie, so it is dropped from the body.
+func withVersion(data []byte, version byte) []byte {
+
+	b := append([]byte(nil), data...)
+	if version < 3 {
+		b = append(b[:binaryHeaderLen], b[binaryHeaderLen+1:]...)
+		length := binary.BigEndian.Uint64(b[len(binaryMagic)+1:])
+		binary.BigEndian.PutUint64(b[len(binaryMagic)+1:], length-1)
+	}
+	b[len(binaryMagic)] = version
+	binary.BigEndian.PutUint32(b[len(b)-4:], crc32.ChecksumIEEE(b[:len(b)-4]))
+	return b
+}
+
+func TestUnmarshalBinaryVersion1(t *testing.T) {
+
+	// Version 1 was the same, less weights and case folding
+	trie := getTrie(3, 'r')
+	trie.InsertData("rubicon", "river")
+	data, _ := trie.MarshalBinary()
+
+	decoded := NewTrie()
+	if err := decoded.UnmarshalBinary(withVersion(data, 1)); err != nil {
+		t.Fatalf("unexpected error decoding version 1: %v", err)
+	}
+	checkValid(t, "version 1", &decoded)
+	if !reflect.DeepEqual(decoded.Entries(), trie.Entries()) {
+		t.Errorf("expected entries %v, but was %v", trie.Entries(), decoded.Entries())
+	}
+
+	trie.InsertWeight("rubens", 3)
+	data, _ = trie.MarshalBinary()
+	if err := decoded.UnmarshalBinary(withVersion(data, 1)); err != ErrCorrupt {
+		t.Errorf("expected a weight in version 1 to be %v, but was %v", ErrCorrupt, err)
+	}
+	if err := decoded.UnmarshalBinary(withVersion(data, binaryVersion+1)); err != ErrBadVersion {
+		t.Errorf("expected a later version to be %v, but was %v", ErrBadVersion, err)
+	}
+}
+
+func TestUnmarshalBinaryVersion2(t *testing.T) {
+
+	// Version 2 was the same, less case folding
+	trie := getTrie(3, 'r')
+	trie.InsertWeight("rubens", 3)
+	data, _ := trie.MarshalBinary()
+
+	decoded := NewTrie()
+	if err := decoded.UnmarshalBinary(withVersion(data, 2)); err != nil {
+		t.Fatalf("unexpected error decoding version 2: %v", err)
+	}
+	checkValid(t, "version 2", &decoded)
+	if _, n := decoded.Find("rubens"); n == nil || n.Weight() != 3 {
+		t.Errorf("expected 'rubens' to keep its weight")
+	}
+
+	folded := NewFoldedTrie()
+	folded.Insert("iPhone")
+	data, _ = folded.MarshalBinary()
+	if err := decoded.UnmarshalBinary(withVersion(data, 2)); err != ErrCorrupt {
+		t.Errorf("expected original terms in version 2 to be %v, but was %v", ErrCorrupt, err)
+	}
+}
+
+func TestBinaryFolded(t *testing.T) {
+
+	trie := NewFoldedTrie()
+	for _, s := range []string{"iPhone", "IPHONE", "Straße", "test"} {
+		trie.Insert(s)
+	}
+	data, err := trie.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	decoded := NewTrie()
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	checkValid(t, "folded", &decoded)
+	if found, _ := decoded.Find("IPhone"); !found {
+		t.Errorf("expected decoded trie to be case-insensitive")
+	}
+	if !reflect.DeepEqual(decoded.Entries(), trie.Entries()) {
+		t.Errorf("expected entries %v, but was %v", trie.Entries(), decoded.Entries())
+	}
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+
+	trie := getTrie(3, 'r')
+	good, _ := trie.MarshalBinary()
+
+	invalid := Trie{child: []*Node{{value: "ro", childCount: 1,
+		children: []*Node{{value: "mane", childCount: 0, entry: true}}}}, count: 1}
+	structural, _ := invalid.MarshalBinary()
+
+	corrupt := func(i int) []byte {
+		b := append([]byte(nil), good...)
+		b[i] ^= 0xff
+		return b
+	}
+
+	errorTests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{
+			name: "empty input",
+			data: []byte{},
+			err:  io.EOF,
+		},
+		{
+			name: "bad magic",
+			data: corrupt(0),
+			err:  ErrBadMagic,
+		},
+		{
+			name: "bad version",
+			data: corrupt(len(binaryMagic)),
+			err:  ErrBadVersion,
+		},
+		{
+			name: "bad checksum",
+			data: corrupt(binaryHeaderLen + 2),
+			err:  ErrChecksum,
+		},
+		{
+			name: "truncated input",
+			data: good[:len(good)-1],
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			name: "structurally invalid trie",
+			data: structural,
+			err:  ErrCorrupt,
+		},
+		{
+			name: "trailing input",
+			data: append(append([]byte(nil), good...), 0),
+			err:  ErrCorrupt,
+		},
+	}
+
+	for _, test := range errorTests {
+		decoded := NewTrie()
+		err := decoded.UnmarshalBinary(test.data)
+		if err != test.err {
+			t.Errorf("test '%s': expected error to be %v, but was %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package trie
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Speller offers spell-checking against the entries of a trie.
+// Suggestions are found by an edit-distance search of the trie in
+// which insertions, deletions, substitutions and transpositions of
+// adjacent runes each cost one - except that substituting a rune
+// for one next to it on the keyboard costs less, as that is a more
+// likely typing error. Suggestions are ranked by cost and then by
+// entry weight (so more frequent words come first).
+type Speller struct {
+	trie *Trie
+
+	// MaxCost is the greatest total edit cost of a suggestion.
+	MaxCost float64
+
+	// Adjacent reports whether two runes are next to each other on
+	// the keyboard. It defaults to a QWERTY layout.
+	Adjacent func(a rune, b rune) bool
+
+	// AdjacentCost is the cost of substituting an adjacent rune.
+	AdjacentCost float64
+}
+
+// NewSpeller returns a Speller for the entries of t, which allows
+// a total edit cost of two and charges half for substitutions of
+// adjacent QWERTY keys.
+func NewSpeller(t *Trie) *Speller {
+	return &Speller{trie: t, MaxCost: 2, Adjacent: QWERTYAdjacent, AdjacentCost: 0.5}
+}
+
+// Check reports whether word is an entry of the trie. As with Find,
+// leading & trailing whitespace is ignored.
+func (s *Speller) Check(word string) bool {
+
+	found, _ := s.trie.Find(word)
+	return found
+}
+
+// Suggest returns up to n entries which are close to word, best
+// first. The word itself is never suggested.
+func (s *Speller) Suggest(word string, n int) []string {
+
+	target := []rune(strings.TrimSpace(word))
+	if n <= 0 || len(target) == 0 {
+		return nil
+	}
+
+	search := suggestSearch{speller: s, target: target}
+	row := make([]float64, len(target)+1)
+	for i := range row {
+		row[i] = float64(i)
+	}
+	for _, c := range s.trie.tree().child {
+		search.node(c, "", row, nil, 0)
+	}
+
+	sort.Slice(search.found, func(i, j int) bool {
+		a, b := search.found[i], search.found[j]
+		if a.cost != b.cost {
+			return a.cost < b.cost
+		}
+		if a.weight != b.weight {
+			return a.weight > b.weight
+		}
+		return a.word < b.word
+	})
+
+	var suggestions []string
+	for _, f := range search.found {
+		if len(suggestions) == n {
+			break
+		}
+		suggestions = append(suggestions, f.word)
+	}
+	return suggestions
+}
+
+type suggestion struct {
+	word   string
+	cost   float64
+	weight int
+}
+
+type suggestSearch struct {
+	speller *Speller
+	target  []rune
+	found   []suggestion
+}
+
+// node extends the edit-distance rows along the value of n, one
+// rune at a time, pruning the search once no cell in a row is
+// within the maximum cost. The rows for the previous rune (and
+// the rune itself) are needed for transpositions.
+func (s *suggestSearch) node(n *Node, path string, row []float64, prevRow []float64, prev rune) {
+
+	path += n.value
+	for _, r := range n.value {
+		next := s.step(row, prevRow, prev, r)
+		if minCost(next) > s.speller.MaxCost {
+			return
+		}
+		prevRow, row, prev = row, next, r
+	}
+
+	cost := row[len(row)-1]
+	if n.entry && cost <= s.speller.MaxCost && cost > 0 {
+		s.found = append(s.found, suggestion{word: path, cost: cost, weight: n.weight})
+	}
+	for _, c := range n.children {
+		s.node(c, path, row, prevRow, prev)
+	}
+}
+
+func (s *suggestSearch) step(row []float64, prevRow []float64, prev rune, r rune) []float64 {
+
+	next := make([]float64, len(row))
+	next[0] = row[0] + 1
+	for i := 1; i < len(row); i++ {
+		t := s.target[i-1]
+		cost := row[i-1] + s.substitution(t, r)
+		if c := row[i] + 1; c < cost {
+			cost = c
+		}
+		if c := next[i-1] + 1; c < cost {
+			cost = c
+		}
+		if prevRow != nil && i > 1 && t == prev && s.target[i-2] == r {
+			if c := prevRow[i-2] + 1; c < cost {
+				cost = c
+			}
+		}
+		next[i] = cost
+	}
+	return next
+}
+
+func (s *suggestSearch) substitution(a rune, b rune) float64 {
+
+	if a == b {
+		return 0
+	}
+	if s.speller.Adjacent != nil && s.speller.Adjacent(a, b) {
+		return s.speller.AdjacentCost
+	}
+	return 1
+}
+
+func minCost(row []float64) float64 {
+
+	m := row[0]
+	for _, v := range row[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+var qwertyRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// QWERTYAdjacent reports whether a and b are next to each other
+// (including diagonally) on a QWERTY keyboard, ignoring case.
+func QWERTYAdjacent(a rune, b rune) bool {
+
+	a, b = unicode.ToLower(a), unicode.ToLower(b)
+	ar, ac := qwertyPosition(a)
+	br, bc := qwertyPosition(b)
+	if ar < 0 || br < 0 || a == b {
+		return false
+	}
+	switch ar - br {
+	case 0:
+		return ac-bc == 1 || bc-ac == 1
+	case 1:
+		// a is on the row below b, which is offset to the right
+		return bc == ac || bc == ac+1
+	case -1:
+		return ac == bc || ac == bc+1
+	}
+	return false
+}
+
+func qwertyPosition(r rune) (int, int) {
+
+	for i, row := range qwertyRows {
+		if j := strings.IndexRune(row, r); j >= 0 {
+			return i, j
+		}
+	}
+	return -1, -1
+}
@@ -0,0 +1,147 @@
+package trie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func getSpellerTrie() Trie {
+
+	trie := NewTrie()
+	for _, w := range []struct {
+		word   string
+		weight int
+	}{
+		{"romane", 1}, {"romanus", 1}, {"romulus", 5}, {"rubens", 1}, {"ruber", 1},
+		{"rubicon", 9}, {"rubicundus", 1}, {"slow", 3}, {"slower", 2}, {"slowly", 4},
+		{"test", 1}, {"toaster", 1}, {"toasting", 1}, {"best", 2}, {"rest", 7},
+	} {
+		trie.InsertWeight(w.word, w.weight)
+	}
+	return trie
+}
+
+func TestSpellerCheck(t *testing.T) {
+
+	trie := getSpellerTrie()
+	checkValid(t, "speller", &trie)
+	speller := NewSpeller(&trie)
+
+	checkTests := []struct {
+		word    string
+		correct bool
+	}{
+		{"rubicon", true},
+		{" slowly\n", true},
+		{"rubicn", false},
+		{"slo", false},
+		{"", false},
+	}
+
+	for _, test := range checkTests {
+		if speller.Check(test.word) != test.correct {
+			t.Errorf("test '%s': expected check to be %t", test.word, test.correct)
+		}
+	}
+}
+
+func TestSpellerSuggest(t *testing.T) {
+
+	trie := getSpellerTrie()
+	speller := NewSpeller(&trie)
+
+	suggestTests := []struct {
+		name     string
+		word     string
+		n        int
+		expected []string
+	}{
+		{
+			name:     "deletion",
+			word:     "rubicn",
+			n:        1,
+			expected: []string{"rubicon"},
+		},
+		{
+			name:     "insertion",
+			word:     "slowwly",
+			n:        1,
+			expected: []string{"slowly"},
+		},
+		{
+			name:     "transposition",
+			word:     "toatser",
+			n:        1,
+			expected: []string{"toaster"},
+		},
+		{
+			name:     "adjacent key is cheaper than distant key",
+			word:     "tesr",
+			n:        3,
+			expected: []string{"test", "rest", "best"},
+		},
+		{
+			name:     "weight breaks ties",
+			word:     "xest",
+			n:        3,
+			expected: []string{"rest", "best", "test"},
+		},
+		{
+			name:     "limit on number of suggestions",
+			word:     "slowe",
+			n:        2,
+			expected: []string{"slow", "slower"},
+		},
+		{
+			name:     "correct word is not suggested",
+			word:     "slow",
+			n:        2,
+			expected: []string{"slowly", "slower"},
+		},
+		{
+			name:     "nothing close enough",
+			word:     "quixotic",
+			n:        3,
+			expected: nil,
+		},
+		{
+			name:     "no suggestions wanted",
+			word:     "rubicn",
+			n:        0,
+			expected: nil,
+		},
+	}
+
+	for _, test := range suggestTests {
+		suggestions := speller.Suggest(test.word, test.n)
+		if !reflect.DeepEqual(suggestions, test.expected) {
+			t.Errorf("test '%s': expected %v, but was %v", test.name, test.expected, suggestions)
+		}
+	}
+}
+
+func TestQWERTYAdjacent(t *testing.T) {
+
+	adjacentTests := []struct {
+		a, b     rune
+		adjacent bool
+	}{
+		{'t', 'r', true},
+		{'r', 't', true},
+		{'a', 'q', true},
+		{'a', 'w', true},
+		{'q', 'a', true},
+		{'z', 's', true},
+		{'G', 'h', true},
+		{'a', 'e', false},
+		{'q', 'p', false},
+		{'a', 'a', false},
+		{'大', 'a', false},
+	}
+
+	for _, test := range adjacentTests {
+		if QWERTYAdjacent(test.a, test.b) != test.adjacent {
+			t.Errorf("test '%c' '%c': expected adjacent to be %t", test.a, test.b, test.adjacent)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package trie
+
+import (
+	"unsafe"
+)
+
+// Stats describes the size and shape of a trie, as returned by
+// Stats. The fanouts count the root rune nodes as the children of
+// the trie itself. The heap footprint is only an estimate, since
+// it cannot see how much memory is shared (edges are often
+// substrings of the inserted terms, which keep the whole term
+// alive) nor the precise overheads of the allocator and of maps.
+type Stats struct {
+	Entries   int
+	Nodes     int     // including the root rune nodes
+	EdgeBytes int     // total length of the node values
+	MaxFanout int     // most children of any node
+	AvgFanout float64 // average children of nodes which have any
+
+	// Depths[d] is the number of nodes at depth d, where the root
+	// rune nodes are at depth 1 (as for DOTOptions.MaxDepth), so
+	// Depths[0] is always zero.
+	Depths []int
+
+	HeapBytes int // estimated, including any suffix index
+}
+
+// Approximate sizes (in bytes) of the memory behind the nodes
+const (
+	sizeOfNode      = int(unsafe.Sizeof(Node{}))
+	sizeOfPointer   = int(unsafe.Sizeof(&Node{}))
+	sizeOfString    = int(unsafe.Sizeof(""))
+	sizeOfRune      = int(unsafe.Sizeof(rune(0)))
+	sizeOfIndex     = int(unsafe.Sizeof(childIndex{}))
+	sizeOfByteIndex = int(unsafe.Sizeof([256]uint16{}))
+	sizeOfMapEntry  = 48 // a rune & an int, plus the map's overhead
+	sizeOfArena     = int(unsafe.Sizeof(nodeArena{}))
+	sizeOfArenaNode = int(unsafe.Sizeof(arenaNode{}))
+)
+
+// Stats walks the trie to gather statistics about it, which may be
+// used to compare configurations (such as case folding, or the
+// suffix index) or to size containers before loading a dictionary.
+func (t *Trie) Stats() Stats {
+
+	s := Stats{Entries: t.count, Depths: []int{0}}
+	s.HeapBytes = int(unsafe.Sizeof(*t))
+
+	var parents, children int
+	if t.arena != nil {
+		parents, children = s.arenaNodes(t.arena)
+	} else {
+		parents, children = s.nodes(t)
+	}
+	if parents > 0 {
+		s.AvgFanout = float64(children) / float64(parents)
+	}
+
+	if t.reversed != nil {
+		s.HeapBytes += t.reversed.Stats().HeapBytes
+	}
+	return s
+}
+
+// depth counts a node at depth d.
+func (s *Stats) depth(d int) {
+
+	for len(s.Depths) <= d {
+		s.Depths = append(s.Depths, 0)
+	}
+	s.Depths[d]++
+}
+
+// nodes gathers the statistics of the Nodes of t, returning the
+// number of nodes with children and the number of their children.
+func (s *Stats) nodes(t *Trie) (int, int) {
+
+	s.HeapBytes += cap(t.child)*sizeOfPointer + s.indexBytes(t.roots)
+
+	parents, children := 0, 0
+	var visit func(n *Node, depth int)
+	visit = func(n *Node, depth int) {
+		s.Nodes++
+		s.EdgeBytes += len(n.value)
+		s.depth(depth)
+
+		if len(n.children) > 0 {
+			parents++
+			children += len(n.children)
+		}
+		s.MaxFanout = max(s.MaxFanout, len(n.children))
+
+		s.HeapBytes += sizeOfNode + len(n.value) + len(n.data)
+		s.HeapBytes += cap(n.children)*sizeOfPointer + s.indexBytes(n.index)
+		s.HeapBytes += cap(n.keys) * sizeOfString
+		for _, k := range n.keys {
+			s.HeapBytes += len(k)
+		}
+
+		for _, c := range n.children {
+			visit(c, depth+1)
+		}
+	}
+	for _, c := range t.child {
+		visit(c, 1)
+	}
+
+	// The roots count towards the fanout of the trie itself
+	if len(t.child) > 0 {
+		parents++
+		children += len(t.child)
+		s.MaxFanout = max(s.MaxFanout, len(t.child))
+	}
+	return parents, children
+}
+
+// arenaNodes gathers the statistics of the nodes in a, as for nodes.
+// The heap footprint of the nodes is just the size of the arena.
+func (s *Stats) arenaNodes(a *nodeArena) (int, int) {
+
+	s.HeapBytes += sizeOfArena + cap(a.nodes)*sizeOfArenaNode + cap(a.bytes)
+
+	parents, children := 0, 0
+	var visit func(n uint32, depth int)
+	visit = func(n uint32, depth int) {
+		fanout := 0
+		for c := a.nodes[n].child; c != 0; c = a.nodes[c].next {
+			fanout++
+			s.Nodes++
+			s.EdgeBytes += int(a.nodes[c].edgeLen)
+			s.depth(depth + 1)
+			visit(c, depth+1)
+		}
+		if fanout > 0 {
+			parents++
+			children += fanout
+		}
+		s.MaxFanout = max(s.MaxFanout, fanout)
+	}
+	visit(0, 0)
+	return parents, children
+}
+
+func (s *Stats) indexBytes(x *childIndex) int {
+
+	if x == nil {
+		return 0
+	}
+	b := sizeOfIndex + cap(x.sorted)*sizeOfRune + cap(x.at)
+	if x.bytes != nil {
+		b += sizeOfByteIndex
+	}
+	return b + len(x.runes)*sizeOfMapEntry
+}
@@ -0,0 +1,99 @@
+package trie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+
+	statsTests := []struct {
+		name     string
+		trie     Trie
+		expected Stats
+	}{
+		{
+			name:     "empty trie",
+			trie:     getTrie(0, 'r'),
+			expected: Stats{Depths: []int{0}},
+		},
+		{
+			name:     "one entry",
+			trie:     getTrie(1, 'r'),
+			expected: Stats{Entries: 1, Nodes: 2, EdgeBytes: 6, MaxFanout: 1, AvgFanout: 1, Depths: []int{0, 1, 1}},
+		},
+		{
+			name:     "entries with a shared prefix",
+			trie:     getTrie(3, 's'),
+			expected: Stats{Entries: 3, Nodes: 4, EdgeBytes: 8, MaxFanout: 2, AvgFanout: 4.0 / 3, Depths: []int{0, 1, 1, 2}},
+		},
+		{
+			name:     "several levels",
+			trie:     getTrie(7, 'r'),
+			expected: Stats{Entries: 7, Nodes: 13, EdgeBytes: 27, MaxFanout: 2, AvgFanout: 13.0 / 7, Depths: []int{0, 1, 2, 4, 6}},
+		},
+		{
+			name:     "chinese entries",
+			trie:     getStringTrie(2, "大"),
+			expected: Stats{Entries: 2, Nodes: 3, EdgeBytes: 9, MaxFanout: 2, AvgFanout: 3.0 / 2, Depths: []int{0, 1, 2}},
+		},
+	}
+
+	for _, test := range statsTests {
+		stats := test.trie.Stats()
+		if stats.HeapBytes <= 0 {
+			t.Errorf("test '%s': expected a positive heap estimate, but was %d", test.name, stats.HeapBytes)
+		}
+		stats.HeapBytes = 0
+		if !reflect.DeepEqual(stats, test.expected) {
+			t.Errorf("test '%s': expected %+v, but was %+v", test.name, test.expected, stats)
+		}
+
+		// An arena-backed trie has the same shape
+		arena := NewArenaTrie()
+		for _, s := range test.trie.Entries() {
+			arena.Insert(s)
+		}
+		arenaStats := arena.Stats()
+		arenaStats.HeapBytes = 0
+		if !reflect.DeepEqual(arenaStats, test.expected) {
+			t.Errorf("test '%s': expected arena %+v, but was %+v", test.name, test.expected, arenaStats)
+		}
+	}
+}
+
+func TestStatsSuffixIndex(t *testing.T) {
+
+	trie := getTrie(7, 'r')
+	before := trie.Stats()
+	trie.IndexSuffixes()
+	after := trie.Stats()
+
+	if after.HeapBytes <= before.HeapBytes {
+		t.Errorf("expected the suffix index to add to the heap estimate (%d, then %d)", before.HeapBytes, after.HeapBytes)
+	}
+	after.HeapBytes, before.HeapBytes = 0, 0
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("expected the suffix index not to change the shape, but was %+v then %+v", before, after)
+	}
+}
+
+func TestStatsArenaSuffixIndex(t *testing.T) {
+
+	trie := NewArenaTrie()
+	for _, s := range benchmarkKeys {
+		trie.Insert(s)
+	}
+	trie.IndexSuffixes()
+
+	// The suffix index has an arena of its own, which is counted too
+	if trie.reversed.arena == nil || trie.reversed.arena == trie.arena {
+		t.Fatalf("expected the suffix index to have its own arena")
+	}
+	alone := trie
+	alone.reversed = nil
+	expected := alone.Stats().HeapBytes + trie.reversed.Stats().HeapBytes
+	if heap := trie.Stats().HeapBytes; heap != expected {
+		t.Errorf("expected a heap estimate of %d, but was %d", expected, heap)
+	}
+}
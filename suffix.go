@@ -0,0 +1,100 @@
+package trie
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// IndexSuffixes makes the trie maintain a second, internal trie of
+// its entries with their runes reversed, which is kept in sync by
+// Insert and Delete. This speeds up WithSuffix and LongestSuffix,
+// at the cost of roughly doubling the memory used. The index is not
+// kept by the encodings (such as MarshalBinary), so it needs to be
+// requested again after decoding.
+func (t *Trie) IndexSuffixes() {
+
+	reversed := NewTrie()
+	if t.arena != nil {
+		reversed = NewArenaTrie()
+	}
+	t.Walk(func(s string, n *Node) bool {
+		reversed.Insert(reverseRunes(s))
+		return true
+	})
+	t.reversed = &reversed
+}
+
+// WithSuffix returns every entry which ends with suffix. Unless the
+// suffixes have been indexed (see IndexSuffixes) this has to check
+// every entry in the trie.
+func (t *Trie) WithSuffix(suffix string) []string {
+
+	if t.reversed == nil {
+		entries := []string{}
+		t.Walk(func(s string, n *Node) bool {
+			if strings.HasSuffix(s, t.foldKey(suffix)) {
+				entries = t.appendKeys(entries, s, n)
+			}
+			return true
+		})
+		return entries
+	}
+
+	entries := t.reversed.WithPrefix(reverseRunes(t.foldKey(suffix)))
+	if t.fold {
+		keys := []string{}
+		for _, s := range entries {
+			s = reverseRunes(s)
+			keys = t.appendKeys(keys, s, t.findNode(s))
+		}
+		return keys
+	}
+	for i, s := range entries {
+		entries[i] = reverseRunes(s)
+	}
+	return entries
+}
+
+// LongestSuffix returns the longest entry which is a suffix of s
+// (which may be s itself), if there is one. Unless the suffixes
+// have been indexed (see IndexSuffixes) this has to look up every
+// suffix of s in turn.
+func (t *Trie) LongestSuffix(s string) (string, bool) {
+
+	if t.reversed != nil {
+		longest, found := t.reversed.LongestPrefix(reverseRunes(t.foldKey(s)))
+		if !found {
+			return "", false
+		}
+		// Folding keeps the runes, though not always their lengths
+		runes := []rune(s)
+		return string(runes[len(runes)-utf8.RuneCountInString(longest):]), true
+	}
+
+	for i := 0; i < len(s); {
+		if n := t.findNode(t.foldKey(s[i:])); n != nil && n.entry {
+			return s[i:], true
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return "", false
+}
+
+func (t *Trie) insertReversed(s string) {
+
+	if t.reversed != nil {
+		t.reversed.Insert(reverseRunes(s))
+	}
+}
+
+// reverseRunes reverses s rune by rune (rather than byte by byte,
+// which would break multi-byte runes).
+func reverseRunes(s string) string {
+
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
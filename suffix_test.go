@@ -0,0 +1,144 @@
+package trie
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestWithSuffix(t *testing.T) {
+
+	suffixTests := []struct {
+		name     string
+		suffix   string
+		expected []string
+	}{
+		{
+			name:     "empty suffix",
+			suffix:   "",
+			expected: []string{"rubicon", "slow", "slower", "slowly", "test", "toaster", "大豆", "黄豆"},
+		},
+		{
+			name:     "suffix of one entry",
+			suffix:   "icon",
+			expected: []string{"rubicon"},
+		},
+		{
+			name:     "suffix of several entries",
+			suffix:   "er",
+			expected: []string{"slower", "toaster"},
+		},
+		{
+			name:     "suffix which is an entry",
+			suffix:   "slow",
+			expected: []string{"slow"},
+		},
+		{
+			name:     "chinese suffix",
+			suffix:   "豆",
+			expected: []string{"大豆", "黄豆"},
+		},
+		{
+			name:     "no entries with the suffix",
+			suffix:   "xyz",
+			expected: []string{},
+		},
+	}
+
+	for _, indexed := range []bool{false, true} {
+		trie := NewTrie()
+		if indexed {
+			// Index part way through, so both building and syncing are used
+			trie.Insert("rubicon")
+			trie.Insert("slow")
+			trie.IndexSuffixes()
+		}
+		for _, s := range []string{"rubicon", "slow", "slower", "slowly", "test", "toaster", "大豆", "黄豆", "romane"} {
+			trie.Insert(s)
+		}
+		trie.Delete("romane")
+		checkValid(t, "suffix", &trie)
+
+		for _, test := range suffixTests {
+			entries := trie.WithSuffix(test.suffix)
+			slices.Sort(entries)
+			if !reflect.DeepEqual(entries, test.expected) {
+				t.Errorf("test '%s' (indexed %t): expected %v, but was %v", test.name, indexed, test.expected, entries)
+			}
+		}
+	}
+}
+
+func TestLongestSuffix(t *testing.T) {
+
+	longestTests := []struct {
+		name     string
+		value    string
+		expected string
+		found    bool
+	}{
+		{
+			name:     "empty string",
+			value:    "",
+			expected: "",
+			found:    false,
+		},
+		{
+			name:     "exact entry",
+			value:    "toaster",
+			expected: "toaster",
+			found:    true,
+		},
+		{
+			name:     "entry preceded by other text",
+			value:    "oven-toaster",
+			expected: "toaster",
+			found:    true,
+		},
+		{
+			name:     "longest of several suffixes",
+			value:    "bestest",
+			expected: "test",
+			found:    true,
+		},
+		{
+			name:     "chinese entry preceded by other text",
+			value:    "吃大豆",
+			expected: "大豆",
+			found:    true,
+		},
+		{
+			name:     "no entry is a suffix",
+			value:    "toast",
+			expected: "",
+			found:    false,
+		},
+	}
+
+	for _, indexed := range []bool{false, true} {
+		trie := NewTrie()
+		for _, s := range []string{"test", "est", "toaster", "大豆"} {
+			trie.Insert(s)
+		}
+		if indexed {
+			trie.IndexSuffixes()
+		}
+		checkValid(t, "suffix", &trie)
+
+		for _, test := range longestTests {
+			longest, found := trie.LongestSuffix(test.value)
+			if longest != test.expected || found != test.found {
+				t.Errorf("test '%s' (indexed %t): expected ('%s', %t), but was ('%s', %t)", test.name, indexed, test.expected, test.found, longest, found)
+			}
+		}
+	}
+}
+
+func TestReverseRunes(t *testing.T) {
+
+	for s, expected := range map[string]string{"": "", "ab": "ba", "大豆油": "油豆大", "añb": "bña"} {
+		if r := reverseRunes(s); r != expected {
+			t.Errorf("test '%s': expected '%s', but was '%s'", s, expected, r)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package trie
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Occurrence is a place where a substring was found by a SuffixTree:
+// within Key, starting Offset bytes in.
+type Occurrence struct {
+	Key    string
+	Offset int
+}
+
+// SuffixTree is a generalized suffix tree, which indexes every
+// suffix of every inserted key so that substrings (not just
+// prefixes) can be searched for in time proportional to their
+// length.
+//
+// Keys are added using Ukkonen's algorithm, whose edges must refer
+// to offsets in the text (so that every leaf can grow by a rune in
+// constant time). Once a key has been added, the tree is mirrored
+// in the same compressed edge Nodes as a Trie - with each node
+// marked as an entry where one or more suffixes end - by splitting
+// the Nodes of the edges which were split and adding the new
+// leaves, so each Insert costs time in proportion to the key. The
+// Nodes are what is searched, so reads do not change the tree, and
+// may be made concurrently (but not while inserting).
+type SuffixTree struct {
+	keys   []string
+	index  map[string]int // one more than the position of each key
+	starts []int          // where each key starts in text
+	text   []rune
+	owner  []int // the key of each rune of text
+	open   []int // the leaves of the key being added
+
+	// Ukkonen's construction
+	nodes        []stNode
+	activeNode   int
+	activeEdge   int
+	activeLength int
+	remainder    int
+
+	// The tree as Nodes, along with the Node of each node (which is
+	// its parent's where its edge is only a terminator)
+	root        *Node
+	occurrences map[*Node][]Occurrence
+	mirror      []*Node
+	splits      []stSplit // the splits made by the key being added
+}
+
+// stNode is a node of the suffix tree under construction, whose
+// edge from its parent is text[start:end]. Leaves are open-ended
+// (end is -1) until their key has been fully added.
+type stNode struct {
+	start    int
+	end      int
+	parent   int
+	children map[rune]int
+	link     int
+	suffix   int // for leaves, where in text the suffix starts
+}
+
+// stSplit records that the edge to next, which began at start, was
+// split by the new node split, length runes along.
+type stSplit struct {
+	split  int
+	next   int
+	start  int
+	length int
+}
+
+// NewSuffixTree returns an empty SuffixTree.
+func NewSuffixTree() *SuffixTree {
+
+	root := makeNode("", false)
+	return &SuffixTree{
+		index:       map[string]int{},
+		nodes:       []stNode{{children: map[rune]int{}, suffix: -1}},
+		root:        &root,
+		occurrences: map[*Node][]Occurrence{},
+		mirror:      []*Node{&root},
+	}
+}
+
+// Count returns the number of keys in the suffix tree.
+func (st *SuffixTree) Count() int {
+	return len(st.keys)
+}
+
+// Insert adds every suffix of s to the suffix tree, returning false
+// if s is empty (once trimmed), is not valid UTF-8 or has already
+// been inserted.
+func (st *SuffixTree) Insert(s string) bool {
+
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" || !utf8.ValidString(trimmed) || st.index[trimmed] > 0 {
+		return false
+	}
+	st.keys = append(st.keys, trimmed)
+	key := len(st.keys) - 1
+	st.index[trimmed] = len(st.keys)
+
+	// Each key is terminated by a unique rune, which cannot appear
+	// in valid UTF-8, so that every suffix ends at a leaf
+	from := len(st.text)
+	st.starts = append(st.starts, from)
+	st.text = append(st.text, []rune(trimmed)...)
+	st.text = append(st.text, -1-rune(key))
+	for range len(st.text) - from {
+		st.owner = append(st.owner, key)
+	}
+
+	for pos := from; pos < len(st.text); pos++ {
+		st.extend(pos)
+	}
+
+	// Close the leaves of this key, which now end at its terminator
+	for _, leaf := range st.open {
+		st.nodes[leaf].end = len(st.text)
+	}
+
+	// Bring the Nodes up to date
+	for _, split := range st.splits {
+		st.mirrorSplit(split)
+	}
+	for _, leaf := range st.open {
+		st.addOccurrence(st.mirrorOf(leaf), st.nodes[leaf].suffix)
+	}
+	st.open = st.open[:0]
+	st.splits = st.splits[:0]
+	return true
+}
+
+func (st *SuffixTree) newNode(start int, end int, suffix int, parent int) int {
+
+	st.nodes = append(st.nodes, stNode{start: start, end: end, parent: parent, children: map[rune]int{}, suffix: suffix})
+	st.mirror = append(st.mirror, nil)
+	if end < 0 {
+		st.open = append(st.open, len(st.nodes)-1)
+	}
+	return len(st.nodes) - 1
+}
+
+func (st *SuffixTree) edgeLength(n int, pos int) int {
+
+	if st.nodes[n].end < 0 {
+		return pos + 1 - st.nodes[n].start
+	}
+	return st.nodes[n].end - st.nodes[n].start
+}
+
+// extend adds text[pos] to every suffix still to be added, as one
+// phase of Ukkonen's algorithm.
+func (st *SuffixTree) extend(pos int) {
+
+	st.remainder++
+	lastNew := -1
+	for st.remainder > 0 {
+		if st.activeLength == 0 {
+			st.activeEdge = pos
+		}
+		r := st.text[st.activeEdge]
+		next, ok := st.nodes[st.activeNode].children[r]
+		if !ok {
+			st.nodes[st.activeNode].children[r] = st.newNode(pos, -1, pos-st.remainder+1, st.activeNode)
+			if lastNew >= 0 {
+				st.nodes[lastNew].link = st.activeNode
+				lastNew = -1
+			}
+		} else {
+			if length := st.edgeLength(next, pos); st.activeLength >= length {
+				// Walk down to the next node
+				st.activeEdge += length
+				st.activeLength -= length
+				st.activeNode = next
+				continue
+			}
+			if st.text[st.nodes[next].start+st.activeLength] == st.text[pos] {
+				// Already present, so this phase is over
+				if lastNew >= 0 && st.activeNode != 0 {
+					st.nodes[lastNew].link = st.activeNode
+				}
+				st.activeLength++
+				return
+			}
+			split := st.newNode(st.nodes[next].start, st.nodes[next].start+st.activeLength, -1, st.activeNode)
+			st.nodes[st.activeNode].children[r] = split
+			st.nodes[split].children[st.text[pos]] = st.newNode(pos, -1, pos-st.remainder+1, split)
+			st.splits = append(st.splits, stSplit{split: split, next: next, start: st.nodes[next].start, length: st.activeLength})
+			st.nodes[next].start += st.activeLength
+			st.nodes[next].parent = split
+			st.nodes[split].children[st.text[st.nodes[next].start]] = next
+			if lastNew >= 0 {
+				st.nodes[lastNew].link = split
+			}
+			lastNew = split
+		}
+
+		st.remainder--
+		if st.activeNode == 0 && st.activeLength > 0 {
+			st.activeLength--
+			st.activeEdge = pos - st.remainder + 1
+		} else if st.activeNode != 0 {
+			st.activeNode = st.nodes[st.activeNode].link
+		}
+	}
+}
+
+// mirrorSplit splits the Node of an edge which was split while
+// adding a key, if it has one (edges of the key's own leaves do
+// not, until they are closed).
+func (st *SuffixTree) mirrorSplit(e stSplit) {
+
+	n := st.mirror[e.next]
+	if n == nil {
+		return
+	}
+	st.mirror[e.split] = n
+
+	// Where only the terminator is left below the split, the suffix
+	// already ends at n
+	i := len(string(st.text[e.start : e.start+e.length]))
+	if i == len(n.value) {
+		return
+	}
+	n.split(i)
+	lower := n.children[0]
+	st.mirror[e.next] = lower
+	if occurrences, ok := st.occurrences[n]; ok {
+		st.occurrences[lower] = occurrences
+		delete(st.occurrences, n)
+	}
+}
+
+// mirrorOf returns the Node of node i, making it (and any of its
+// parents') if need be. The key terminators are dropped from the
+// edges, and where this leaves an edge empty its Node is its
+// parent's.
+func (st *SuffixTree) mirrorOf(i int) *Node {
+
+	if st.mirror[i] != nil {
+		return st.mirror[i]
+	}
+	parent := st.mirrorOf(st.nodes[i].parent)
+	edge := st.text[st.nodes[i].start:st.nodes[i].end]
+	if last := len(edge) - 1; edge[last] < 0 {
+		edge = edge[:last]
+	}
+	st.mirror[i] = parent
+	if len(edge) > 0 {
+		st.mirror[i] = parent.makeChildNode(string(edge), false)
+	}
+	return st.mirror[i]
+}
+
+func (st *SuffixTree) addOccurrence(n *Node, suffix int) {
+
+	if st.text[suffix] < 0 {
+		// The empty suffix, made of the terminator alone
+		return
+	}
+	key := st.owner[suffix]
+	offset := len(string(st.text[st.starts[key]:suffix]))
+	n.entry = true
+	st.occurrences[n] = append(st.occurrences[n], Occurrence{Key: st.keys[key], Offset: offset})
+}
+
+// Contains reports whether sub is a substring of any key. The empty
+// string is not contained in anything.
+func (st *SuffixTree) Contains(sub string) bool {
+
+	if sub == "" {
+		return false
+	}
+	n, _ := prefixNode(st.root, sub, "")
+	return n != nil
+}
+
+// Occurrences returns every place where sub is found in the keys,
+// ordered by when the key was inserted and then by offset.
+func (st *SuffixTree) Occurrences(sub string) []Occurrence {
+
+	if sub == "" {
+		return nil
+	}
+	n, _ := prefixNode(st.root, sub, "")
+	if n == nil {
+		return nil
+	}
+
+	var found []Occurrence
+	walkNode(n, "", func(_ string, e *Node) bool {
+		found = append(found, st.occurrences[e]...)
+		return true
+	})
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Key != found[j].Key {
+			return st.index[found[i].Key] < st.index[found[j].Key]
+		}
+		return found[i].Offset < found[j].Offset
+	})
+	return found
+}
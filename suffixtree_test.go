@@ -0,0 +1,165 @@
+package trie
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSuffixTree(t *testing.T) {
+
+	st := NewSuffixTree()
+	for _, s := range []string{"banana", "bandana", " ananas\n", "大豆油", "豆油", "banana", ""} {
+		st.Insert(s)
+	}
+	if st.Count() != 5 {
+		t.Errorf("expected count to be 5, but was %d", st.Count())
+	}
+
+	suffixTests := []struct {
+		name     string
+		sub      string
+		expected []Occurrence
+	}{
+		{
+			name: "repeated substring",
+			sub:  "ana",
+			expected: []Occurrence{
+				{Key: "banana", Offset: 1},
+				{Key: "banana", Offset: 3},
+				{Key: "bandana", Offset: 4},
+				{Key: "ananas", Offset: 0},
+				{Key: "ananas", Offset: 2},
+			},
+		},
+		{
+			name:     "whole key",
+			sub:      "bandana",
+			expected: []Occurrence{{Key: "bandana", Offset: 0}},
+		},
+		{
+			name:     "suffix of keys",
+			sub:      "na",
+			expected: []Occurrence{{Key: "banana", Offset: 2}, {Key: "banana", Offset: 4}, {Key: "bandana", Offset: 5}, {Key: "ananas", Offset: 1}, {Key: "ananas", Offset: 3}},
+		},
+		{
+			name:     "chinese substring",
+			sub:      "油",
+			expected: []Occurrence{{Key: "大豆油", Offset: 6}, {Key: "豆油", Offset: 3}},
+		},
+		{
+			name:     "longer than any key",
+			sub:      "bananas",
+			expected: nil,
+		},
+		{
+			name:     "absent substring",
+			sub:      "nab",
+			expected: nil,
+		},
+		{
+			name:     "empty substring",
+			sub:      "",
+			expected: nil,
+		},
+	}
+
+	for _, test := range suffixTests {
+		found := st.Occurrences(test.sub)
+		if !reflect.DeepEqual(found, test.expected) {
+			t.Errorf("test '%s': expected %v, but was %v", test.name, test.expected, found)
+		}
+		if contains := st.Contains(test.sub); contains != (test.expected != nil) {
+			t.Errorf("test '%s': expected contains to be %t", test.name, test.expected != nil)
+		}
+	}
+}
+
+func TestSuffixTreeAllSubstrings(t *testing.T) {
+
+	keys := []string{"mississippi", "missing", "sip", "ssss", "issi", "pipis"}
+	st := NewSuffixTree()
+	for i, k := range keys {
+		st.Insert(k)
+
+		// Check every substring of every key inserted so far, to make
+		// sure that searches after each insert see the new key
+		for _, key := range keys[:i+1] {
+			for start := range key {
+				for end := start + 1; end <= len(key); end++ {
+					sub := key[start:end]
+					var expected []Occurrence
+					for _, k := range keys[:i+1] {
+						for j := 0; j+len(sub) <= len(k); j++ {
+							if strings.HasPrefix(k[j:], sub) {
+								expected = append(expected, Occurrence{Key: k, Offset: j})
+							}
+						}
+					}
+					if found := st.Occurrences(sub); !reflect.DeepEqual(found, expected) {
+						t.Fatalf("'%s' after inserting '%s': expected %v, but was %v", sub, k, expected, found)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSuffixTreeRandom(t *testing.T) {
+
+	// A small alphabet makes for many repeats, and so many splits
+	// while adding each key
+	r := rand.New(rand.NewSource(1))
+	st := NewSuffixTree()
+	var keys []string
+	for len(keys) < 60 {
+		b := make([]byte, 1+r.Intn(12))
+		for i := range b {
+			b[i] = "ab"[r.Intn(2)]
+		}
+		if !st.Insert(string(b)) {
+			continue
+		}
+		keys = append(keys, string(b))
+
+		for _, sub := range []string{"a", "b", "ab", "ba", "aab", "bba", "abab", "aaaa", string(b)} {
+			var expected []Occurrence
+			for _, k := range keys {
+				for j := 0; j+len(sub) <= len(k); j++ {
+					if strings.HasPrefix(k[j:], sub) {
+						expected = append(expected, Occurrence{Key: k, Offset: j})
+					}
+				}
+			}
+			if found := st.Occurrences(sub); !reflect.DeepEqual(found, expected) {
+				t.Fatalf("'%s' after inserting '%s': expected %v, but was %v", sub, b, expected, found)
+			}
+		}
+	}
+}
+
+func TestSuffixTreeConcurrentReads(t *testing.T) {
+
+	st := NewSuffixTree()
+	for _, s := range []string{"banana", "bandana", "ananas"} {
+		st.Insert(s)
+	}
+
+	// Reads do not change the tree, so may run together
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if len(st.Occurrences("ana")) != 5 || !st.Contains("dan") {
+					t.Errorf("unexpected result from a concurrent read")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -3,14 +3,19 @@ package trie
 
 import (
 	//  "fmt"
+	"slices"
 	"strings"
 	"unicode/utf8"
 )
 
 // Trie is a radix trie implementation.
 type Trie struct {
-	child []*Node
-	count int
+	child    []*Node
+	count    int
+	reversed *Trie
+	fold     bool
+	roots    *childIndex
+	arena    *nodeArena
 }
 
 // NewTrie is used to create a new radix trie.
@@ -43,76 +48,225 @@ func (t *Trie) Insert(s string) bool {
 	}
 
 	// Sanity check (same again but for runes)
-	if utf8.RuneCount([]byte(s)) < 2 {
+	if utf8.RuneCountInString(trimmed) < 2 {
 		return false
 	}
 
-	if t.count == 0 {
-		t.makeRuneNode(s)
-		return true
+	// Edges must split on rune boundaries
+	if !utf8.ValidString(trimmed) {
+		return false
+	}
+
+	key := t.foldKey(trimmed)
+	if t.fold {
+		// A new spelling of an existing entry
+		if n := t.findNode(key); n != nil && n.entry {
+			if slices.Contains(n.keys, trimmed) {
+				return false
+			}
+			n.keys = append(n.keys, trimmed)
+			return true
+		}
+	}
+
+	if t.arena != nil {
+		if !t.arena.insert(key) {
+			return false
+		}
+		t.count++
+	} else if _, c := t.root(key); c != nil {
+		_, size := utf8.DecodeRuneInString(key)
+		if !t.insertRuneNode(c, key[size:]) {
+			return false
+		}
+	} else {
+		t.makeRuneNode(key)
 	}
 
-	// Check for duplicate nodes
-	if t.findNode(trimmed) != nil {
+	if t.fold {
+		t.findNode(key).keys = []string{trimmed}
+	}
+	t.insertReversed(key)
+	return true
+}
+
+// InsertData is used to add a new term to the trie along
+// with some optional data, which may later be retrieved
+// from the entry node with Data. As with Insert, this
+// will return false if the term could not be added.
+func (t *Trie) InsertData(s string, data string) bool {
+
+	if !t.Insert(s) {
 		return false
 	}
+	return t.setData(t.foldKey(strings.TrimSpace(s)), data)
+}
 
-	for _, c := range t.child {
-		//fmt.Println()
-		if c.value == s[:1] {
-			//fmt.Printf("findNode child matched: %[01]v %[01]T\n", c)
-			beheaded := s[1:]
-			return t.insertRuneNode(c, c, beheaded)
+// InsertWeight is used to add a new term to the trie along
+// with a weight (such as a frequency), which may later be
+// retrieved from the entry node with Weight. As with Insert,
+// this will return false if the term could not be added.
+func (t *Trie) InsertWeight(s string, weight int) bool {
+
+	if !t.Insert(s) {
+		return false
+	}
+	return t.setWeight(t.foldKey(strings.TrimSpace(s)), weight)
+}
+
+// setData sets the data of the node for key, returning false if
+// there is no such node.
+func (t *Trie) setData(key string, data string) bool {
+
+	if t.arena != nil {
+		i := t.arena.find(key)
+		if i != 0 {
+			t.arena.setData(i, data)
 		}
+		return i != 0
 	}
 
-	t.makeRuneNode(s)
+	n := t.findNode(key)
+	if n == nil {
+		return false
+	}
+	n.data = data
 	return true
 }
 
-func (t *Trie) insertRuneNode(parent *Node, n *Node, s string) bool {
+// setWeight sets the weight of the node for key, returning false
+// if there is no such node.
+func (t *Trie) setWeight(key string, weight int) bool {
 
-	for _, c := range n.children {
+	if t.arena != nil {
+		i := t.arena.find(key)
+		if i != 0 {
+			t.arena.nodes[i].weight = weight
+		}
+		return i != 0
+	}
+
+	n := t.findNode(key)
+	if n == nil {
+		return false
+	}
+	n.weight = weight
+	return true
+}
+
+func (t *Trie) insertRuneNode(n *Node, s string) bool {
+
+	if _, c := n.child(s); c != nil {
 		index := t.findRuneMatch(c.value, s)
-		//fmt.Printf("insertRuneMatch: '%s' '%s' %d len(s) = %d\n", c.value, s, index, len(s))
-		lenC := len(c.value)
-		if index > 0 {
-			if index == lenC {
-				return t.insertRuneNode(c, c, s[index:])
-			}
-			if index < lenC {
-				child := c.makeChildNode(c.value[index:], true)
-				child.children = c.children
-				child.childCount = 0
-				c.setChildNode(child)
-				//fmt.Printf("c.value: %s\n", c.value)
-				c.value = c.value[:index]
-				//fmt.Printf("c.value: %s\n", c.value)
-				c.entry = false
+		if index < len(c.value) {
+			c.split(index)
+		}
+		if index == len(s) {
+			// Check for duplicate entries
+			if c.entry {
+				return false
 			}
-			//fmt.Printf("making child node: %s\n", s[index:])
-			c.makeChildNode(s[index:], true)
+			c.entry = true
 			t.count++
 			return true
 		}
+		return t.insertRuneNode(c, s[index:])
 	}
 
-	// No match in the children so attach to the parent node
-	//fmt.Printf("parented.value2: %s\n", s)
-	parent.makeChildNode(s, true)
+	// No match in the children so attach to this node
+	n.makeChildNode(s, true)
 	t.count++
 	return true
 }
 
 func (t *Trie) makeRuneNode(s string) {
-	rootRune := makeNode(s[:1], false)
-	rootChild := makeNode(s[1:], true)
-	rootRune.children = []*Node{&rootChild}
-	rootRune.childCount = 1
-	t.child = []*Node{&rootRune}
+	_, size := utf8.DecodeRuneInString(s)
+	rootRune := makeNode(s[:size], false)
+	rootChild := makeNode(s[size:], true)
+	rootRune.setChildNode(&rootChild)
+	t.child = append(t.child, &rootRune)
+	t.roots = t.roots.added(t.child)
 	t.count++
 }
 
+// Delete is used to remove a term from the trie, returning false
+// if it was not an entry. Any nodes which are left without a
+// purpose are removed (or merged with their only child), so that
+// the trie is just as if the term had never been inserted.
+func (t *Trie) Delete(s string) bool {
+
+	// Remove leading & trailing whitespace
+	trimmed := strings.TrimSpace(s)
+
+	// Sanity check (should catch empty strings too)
+	if len(trimmed) < 2 {
+		return false
+	}
+
+	trimmed = t.foldKey(trimmed)
+	if t.arena != nil {
+		if !t.arena.delete(0, trimmed) {
+			return false
+		}
+	} else if !t.deleteRoot(trimmed) {
+		return false
+	}
+	t.count--
+	if t.reversed != nil {
+		t.reversed.Delete(reverseRunes(trimmed))
+	}
+	return true
+}
+
+// deleteRoot removes the entry s from beneath its root node, and
+// then removes the root if it is left without children.
+func (t *Trie) deleteRoot(s string) bool {
+
+	i, c := t.root(s)
+	if c == nil {
+		return false
+	}
+	_, size := utf8.DecodeRuneInString(s)
+	if !t.deleteRuneNode(c, s[size:]) {
+		return false
+	}
+	if len(c.children) == 0 {
+		t.child = append(t.child[:i], t.child[i+1:]...)
+		t.roots = indexChildren(t.child)
+	}
+	return true
+}
+
+// deleteRuneNode removes the entry s from beneath n, and then
+// tidies up n's children.
+func (t *Trie) deleteRuneNode(n *Node, s string) bool {
+
+	i, c := n.child(s)
+	if c != nil && strings.HasPrefix(s, c.value) {
+		if len(s) == len(c.value) {
+			if !c.entry {
+				return false
+			}
+			c.entry = false
+			c.data = ""
+			c.weight = 0
+			c.keys = nil
+		} else if !t.deleteRuneNode(c, s[len(c.value):]) {
+			return false
+		}
+
+		switch {
+		case c.entry:
+		case len(c.children) == 0:
+			n.removeChild(i)
+		case len(c.children) == 1:
+			c.merge()
+		}
+		return true
+	}
+	return false
+}
+
 // Find is used to search for a specific term in the trie.
 func (t *Trie) Find(s string) (bool, *Node) {
 
@@ -124,60 +278,204 @@ func (t *Trie) Find(s string) (bool, *Node) {
 		return false, nil
 	}
 
-	n := t.findNode(trimmed)
-	return n != nil, n
+	n := t.findNode(t.foldKey(trimmed))
+	if n == nil || !n.entry {
+		return false, nil
+	}
+	return true, n
 }
 
-func (t *Trie) findNode(s string) *Node {
+// Walk calls fn for every entry in the trie, passing the full
+// term (folded, in a case-insensitive trie) along with the node
+// which is terminal for it. Entries are visited depth-first in
+// the order in which their nodes were created (the trie is not
+// sorted). The walk stops early if fn returns false.
+func (t *Trie) Walk(fn func(s string, n *Node) bool) {
 
+	if t.arena != nil {
+		t.arena.walk(0, "", fn)
+		return
+	}
 	for _, c := range t.child {
-		if c.value == s[:1] {
-			//fmt.Println()
-			beheaded := s[1:]
-			return t.findRuneNode(c, beheaded)
+		if !walkNode(c, c.value, fn) {
+			return
+		}
+	}
+}
+
+// Entries returns every term that has been inserted into the
+// trie, in the order described for Walk.
+func (t *Trie) Entries() []string {
+
+	entries := make([]string, 0, t.count)
+	t.Walk(func(s string, n *Node) bool {
+		entries = t.appendKeys(entries, s, n)
+		return true
+	})
+	return entries
+}
+
+// WithPrefix returns every entry which begins with prefix, in
+// the order described for Walk. Unlike Find, the prefix is not
+// trimmed (so that "ice " may be used to complete "ice cream").
+func (t *Trie) WithPrefix(prefix string) []string {
+
+	entries := []string{}
+	collect := func(s string, n *Node) bool {
+		entries = t.appendKeys(entries, s, n)
+		return true
+	}
+
+	prefix = t.foldKey(prefix)
+	if prefix == "" {
+		t.Walk(collect)
+		return entries
+	}
+
+	if t.arena != nil {
+		if n, path, ok := t.arena.prefix(prefix); ok {
+			t.arena.walk(n, path, collect)
+		}
+	} else if _, c := t.root(prefix); c != nil {
+		_, size := utf8.DecodeRuneInString(prefix)
+		if n, path := prefixNode(c, prefix[size:], c.value); n != nil {
+			walkNode(n, path, collect)
+		}
+	}
+	return entries
+}
+
+// LongestPrefix returns the longest entry which is a prefix of
+// s (which may be s itself), if there is one.
+func (t *Trie) LongestPrefix(s string) (string, bool) {
+
+	longest := 0
+	t.walkPrefixes(t.foldKey(s), func(end int, n *Node) {
+		longest = end
+	})
+	if t.fold {
+		longest = unfoldOffset(s, longest)
+	}
+	return s[:longest], longest > 0
+}
+
+// walkPrefixes calls fn for every entry which is a prefix of s,
+// shortest first, passing the length of the entry (in bytes) and
+// the node which is terminal for it.
+func (t *Trie) walkPrefixes(s string, fn func(end int, n *Node)) {
+
+	if s == "" {
+		return
+	}
+	if t.arena != nil {
+		t.arena.walkPrefixes(s, fn)
+		return
+	}
+
+	_, size := utf8.DecodeRuneInString(s)
+	_, n := t.root(s)
+
+	matched := size
+	for n != nil {
+		if n.entry {
+			fn(matched, n)
+		}
+		_, next := n.child(s[matched:])
+		if next == nil || !strings.HasPrefix(s[matched:], next.value) {
+			break
+		}
+		matched += len(next.value)
+		n = next
+	}
+}
+
+// prefixNode descends from n along rest, returning the highest
+// node whose path (which is also returned) begins with rest.
+func prefixNode(n *Node, rest string, path string) (*Node, string) {
+
+	if rest == "" {
+		return n, path
+	}
+	_, c := n.child(rest)
+	switch {
+	case c == nil:
+	case strings.HasPrefix(rest, c.value):
+		return prefixNode(c, rest[len(c.value):], path+c.value)
+	case strings.HasPrefix(c.value, rest):
+		return c, path + c.value
+	}
+	return nil, ""
+}
+
+func walkNode(n *Node, prefix string, fn func(string, *Node) bool) bool {
+
+	if n.entry && !fn(prefix, n) {
+		return false
+	}
+	for _, c := range n.children {
+		if !walkNode(c, prefix+c.value, fn) {
+			return false
 		}
 	}
+	return true
+}
+
+func (t *Trie) findNode(s string) *Node {
+
+	if t.arena != nil {
+		if i := t.arena.find(s); i != 0 {
+			return t.arena.view(i)
+		}
+		return nil
+	}
+
+	if _, c := t.root(s); c != nil {
+		_, size := utf8.DecodeRuneInString(s)
+		return t.findRuneNode(c, s[size:])
+	}
 
 	// Runaway check
 	return nil
 }
 
+// root returns the root node for the first rune of s, along with
+// its position, or nil.
+func (t *Trie) root(s string) (int, *Node) {
+
+	if s == "" {
+		return -1, nil
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	i := t.roots.find(t.child, r)
+	if i < 0 {
+		return -1, nil
+	}
+	return i, t.child[i]
+}
+
 func (t *Trie) findRuneNode(n *Node, s string) *Node {
 
-	for _, c := range n.children {
-		index := t.findRuneMatch(c.value, s)
-		//fmt.Printf("findRuneMatch: '%s' '%s' %d len(s) = %d\n", c.value, s, index, len(s))
-		lenC := len(c.value)
-		if index > 0 {
-			if index == lenC && index == len(s) {
-				return c
-			}
-			if c.value == s {
-				return c
-			}
-			if c.childCount > 0 {
-				return t.findRuneNode(c, s[index:])
-			}
+	if _, c := n.child(s); c != nil && strings.HasPrefix(s, c.value) {
+		if len(s) == len(c.value) {
+			return c
 		}
+		return t.findRuneNode(c, s[len(c.value):])
 	}
 	return nil
 }
 
+// findRuneMatch returns the length in bytes of the common
+// prefix of v and s, which will always fall on a rune
+// boundary.
 func (t *Trie) findRuneMatch(v string, s string) int {
 
 	res := 0
-	sLen := len(s)
-	runes := []rune(s)
-
-	for i, c := range v {
-
-		if i > sLen {
-			return res
-		}
-		if c != runes[i] {
+	for res < len(v) {
+		_, size := utf8.DecodeRuneInString(v[res:])
+		if res+size > len(s) || v[res:res+size] != s[res:res+size] {
 			return res
 		}
-		res++
+		res += size
 	}
 	return res
 }
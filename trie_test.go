@@ -1,10 +1,14 @@
 package trie
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestIsEmpty(t *testing.T) {
 
 	emptyTrie := NewTrie()
+	checkValid(t, "empty trie", &emptyTrie)
 
 	empty := emptyTrie.isEmpty()
 	if empty != true {
@@ -20,6 +24,7 @@ func TestIsNotEmpty(t *testing.T) {
 	if inserted != true {
 		t.Errorf("Expected inserted to be 'true'")
 	}
+	checkValid(t, "romane", &test)
 
 	empty := test.isEmpty()
 	if empty != false {
@@ -68,12 +73,17 @@ func TestInsertChinese(t *testing.T) {
 
 	for _, test := range insertTests {
 		inserted := test.trie.Insert(test.value)
+		checkValid(t, test.name, &test.trie)
 		if inserted != test.inserted {
 			t.Errorf("test '%s': expected inserted to be %t", test.name, test.inserted)
 		}
 		if test.trie.Count() != test.expectedCount {
 			t.Errorf("test '%s': expected count to be %d, but was %d", test.name, test.expectedCount, test.trie.Count())
 		}
+		expected := getStringTrie(test.expectedCount, "大")
+		if test.trie.String() != expected.String() {
+			t.Errorf("test '%s': expected structure\n%s\nbut was\n%s", test.name, expected.String(), test.trie.String())
+		}
 	}
 }
 
@@ -107,6 +117,13 @@ func TestInsertR(t *testing.T) {
 			expectedCount: 0,
 			inserted:      false,
 		},
+		{
+			name:          "insert into empty trie (invalid UTF-8)",
+			value:         "rom\xffane",
+			trie:          getTrie(0, 'r'),
+			expectedCount: 0,
+			inserted:      false,
+		},
 		{
 			name:          "insert into empty trie",
 			value:         "romane",
@@ -167,12 +184,17 @@ func TestInsertR(t *testing.T) {
 
 	for _, test := range insertTests {
 		inserted := test.trie.Insert(test.value)
+		checkValid(t, test.name, &test.trie)
 		if inserted != test.inserted {
 			t.Errorf("test '%s': expected inserted to be %t", test.name, test.inserted)
 		}
 		if test.trie.Count() != test.expectedCount {
 			t.Errorf("test '%s': expected count to be %d, but was %d", test.name, test.expectedCount, test.trie.Count())
 		}
+		expected := getTrie(test.expectedCount, 'r')
+		if test.trie.String() != expected.String() {
+			t.Errorf("test '%s': expected structure\n%s\nbut was\n%s", test.name, expected.String(), test.trie.String())
+		}
 	}
 }
 
@@ -217,12 +239,17 @@ func TestInsertS(t *testing.T) {
 
 	for _, test := range insertTests {
 		inserted := test.trie.Insert(test.value)
+		checkValid(t, test.name, &test.trie)
 		if inserted != test.inserted {
 			t.Errorf("test 's' '%s': expected inserted to be %t", test.name, test.inserted)
 		}
 		if test.trie.Count() != test.expectedCount {
 			t.Errorf("test 's' '%s': expected count to be %d, but was %d", test.name, test.expectedCount, test.trie.Count())
 		}
+		expected := getTrie(test.expectedCount, 's')
+		if test.trie.String() != expected.String() {
+			t.Errorf("test 's' '%s': expected structure\n%s\nbut was\n%s", test.name, expected.String(), test.trie.String())
+		}
 	}
 }
 
@@ -258,6 +285,7 @@ func TestInsertFindChinese(t *testing.T) {
 
 	for _, test := range insertTests {
 		inserted := trie.Insert(test.value)
+		checkValid(t, test.name, &trie)
 		if inserted != test.inserted {
 			t.Errorf("test '%s': expected inserted to be %t", test.name, test.inserted)
 		}
@@ -343,6 +371,7 @@ func TestInsertFindS(t *testing.T) {
 
 	for _, test := range insertTests {
 		inserted := trie.Insert(test.value)
+		checkValid(t, test.name, &trie)
 		if inserted != test.inserted {
 			t.Errorf("test 's' '%s': expected inserted to be %t", test.name, test.inserted)
 		}
@@ -438,12 +467,17 @@ func TestInsertT(t *testing.T) {
 
 	for _, test := range insertTests {
 		inserted := test.trie.Insert(test.value)
+		checkValid(t, test.name, &test.trie)
 		if inserted != test.inserted {
 			t.Errorf("test 't' '%s': expected inserted to be %t", test.name, test.inserted)
 		}
 		if test.trie.Count() != test.expectedCount {
 			t.Errorf("test 't' '%s': expected count to be %d, but was %d", test.name, test.expectedCount, test.trie.Count())
 		}
+		expected := getTrie(test.expectedCount, 't')
+		if test.trie.String() != expected.String() {
+			t.Errorf("test 't' '%s': expected structure\n%s\nbut was\n%s", test.name, expected.String(), test.trie.String())
+		}
 	}
 }
 
@@ -485,6 +519,7 @@ func TestInsertFindT(t *testing.T) {
 
 	for _, test := range insertTests {
 		inserted := trie.Insert(test.value)
+		checkValid(t, test.name, &trie)
 		if inserted != test.inserted {
 			t.Errorf("test 't' '%s': expected inserted to be %t", test.name, test.inserted)
 		}
@@ -540,6 +575,80 @@ func TestInsertFindT(t *testing.T) {
 	}
 }
 
+func TestInsertFindMixed(t *testing.T) {
+
+	trie := NewTrie()
+
+	// Inserting a prefix of an existing entry must split the
+	// existing edge, and distinct initial runes need roots
+	for _, s := range []string{"slower", "slow", "romane", "roman", "大蒜", "大豆"} {
+		if !trie.Insert(s) {
+			t.Errorf("expected '%s' to be inserted", s)
+		}
+		checkValid(t, s, &trie)
+	}
+	if trie.Count() != 6 {
+		t.Errorf("expected count to be 6, but was %d", trie.Count())
+	}
+
+	findTests := []struct {
+		name   string
+		value  string
+		found  bool
+		isLeaf bool
+	}{
+		{
+			name:   "find entry which was split",
+			value:  "slower",
+			found:  true,
+			isLeaf: true,
+		},
+		{
+			name:   "find prefix which was inserted after its extension",
+			value:  "slow",
+			found:  true,
+			isLeaf: false,
+		},
+		{
+			name:   "find entry under second root",
+			value:  "roman",
+			found:  true,
+			isLeaf: false,
+		},
+		{
+			name:   "find chinese entry under third root",
+			value:  "大豆",
+			found:  true,
+			isLeaf: true,
+		},
+		{
+			name:   "find prefix which is not an entry",
+			value:  "slo",
+			found:  false,
+			isLeaf: false,
+		},
+	}
+
+	for _, test := range findTests {
+		found, n := trie.Find(test.value)
+		if found != test.found {
+			t.Errorf("test '%s': expected found to be %t", test.name, test.found)
+		}
+		if found && n.IsLeaf() != test.isLeaf {
+			t.Errorf("test '%s': expected isLeaf to be %t", test.name, test.isLeaf)
+		}
+	}
+}
+
+// checkValid fails the test if the trie does not satisfy its
+// structural invariants, showing the structure that was found.
+func checkValid(t *testing.T, name string, trie *Trie) {
+	t.Helper()
+	if err := trie.Validate(); err != nil {
+		t.Errorf("test '%s': %v\n%s", name, err, trie.String())
+	}
+}
+
 func getTrie(nodes int, prefix byte) Trie {
 
 	emptyTrie := NewTrie()
@@ -581,7 +690,7 @@ func getTrie(nodes int, prefix byte) Trie {
 							{value: "us", childCount: 0, entry: true}},
 						childCount: 2, entry: false},
 						{value: "ulus", childCount: 0, entry: true}}, childCount: 2, entry: false},
-					{value: "ubens", childCount: 0, entry: true}}, childCount: 1, entry: false}}, count: 4}
+					{value: "ubens", childCount: 0, entry: true}}, childCount: 2, entry: false}}, count: 4}
 		}
 		if nodes == 5 {
 			return Trie{child: []*Node{{
@@ -595,7 +704,7 @@ func getTrie(nodes int, prefix byte) Trie {
 					{value: "ube",
 						children: []*Node{{value: "ns", childCount: 0, entry: true},
 							{value: "r", childCount: 0, entry: true}},
-						childCount: 2, entry: false}}, childCount: 1, entry: false}}, count: 5}
+						childCount: 2, entry: false}}, childCount: 2, entry: false}}, count: 5}
 		}
 		if nodes == 6 {
 			return Trie{child: []*Node{{
@@ -611,7 +720,7 @@ func getTrie(nodes int, prefix byte) Trie {
 							children: []*Node{{value: "ns", childCount: 0, entry: true},
 								{value: "r", childCount: 0, entry: true}}, childCount: 2, entry: false},
 							{value: "icon", childCount: 0, entry: true}}, childCount: 2, entry: false}},
-				childCount: 1, entry: false}}, count: 6}
+				childCount: 2, entry: false}}, count: 6}
 		}
 		if nodes == 7 {
 			return Trie{child: []*Node{{
@@ -630,7 +739,7 @@ func getTrie(nodes int, prefix byte) Trie {
 								children: []*Node{{value: "on", childCount: 0, entry: true},
 									{value: "undus", childCount: 0, entry: true}},
 								childCount: 2, entry: false}},
-						childCount: 2, entry: false}}, childCount: 1, entry: false}}, count: 7}
+						childCount: 2, entry: false}}, childCount: 2, entry: false}}, count: 7}
 		}
 	}
 	if prefix == 's' {
@@ -646,7 +755,7 @@ func getTrie(nodes int, prefix byte) Trie {
 				value: "s",
 				children: []*Node{{value: "low",
 					children:   []*Node{{value: "er", childCount: 0, entry: true}},
-					childCount: 2, entry: true}}, childCount: 1, entry: false}}, count: 2}
+					childCount: 1, entry: true}}, childCount: 1, entry: false}}, count: 2}
 		}
 		if nodes == 3 {
 			return Trie{child: []*Node{{
@@ -670,7 +779,7 @@ func getTrie(nodes int, prefix byte) Trie {
 				value: "t",
 				children: []*Node{{value: "est",
 					childCount: 0, entry: true}, {value: "oaster",
-					childCount: 0, entry: true}}, childCount: 1, entry: false}}, count: 2}
+					childCount: 0, entry: true}}, childCount: 2, entry: false}}, count: 2}
 		}
 		if nodes == 3 {
 			return Trie{child: []*Node{{
@@ -705,7 +814,7 @@ func getStringTrie(nodes int, prefix string) Trie {
 				value: "大",
 				children: []*Node{{value: "蒜",
 					childCount: 0, entry: true}, {value: "豆",
-					childCount: 0, entry: true}}, childCount: 1, entry: false}}, count: 2}
+					childCount: 0, entry: true}}, childCount: 2, entry: false}}, count: 2}
 		}
 	}
 	return emptyTrie
@@ -1084,6 +1193,7 @@ var findTests = []struct {
 func TestFind(t *testing.T) {
 
 	for _, test := range findTests {
+		checkValid(t, test.name, &test.trie)
 		found, n := test.trie.Find(test.value)
 		if found != test.found {
 			t.Errorf("test '%s': expected found to be %t", test.name, test.found)
@@ -1248,3 +1358,226 @@ func BenchmarkFind(b *testing.B) {
 		benchmarkN = n
 	}
 }
+
+func TestWithPrefix(t *testing.T) {
+
+	prefixTests := []struct {
+		name     string
+		prefix   string
+		trie     Trie
+		expected []string
+	}{
+		{
+			name:     "empty prefix in empty trie",
+			prefix:   "",
+			trie:     getTrie(0, 'r'),
+			expected: []string{},
+		},
+		{
+			name:     "empty prefix",
+			prefix:   "",
+			trie:     getTrie(3, 's'),
+			expected: []string{"slow", "slower", "slowly"},
+		},
+		{
+			name:     "prefix ending on a node boundary",
+			prefix:   "rub",
+			trie:     getTrie(7, 'r'),
+			expected: []string{"rubens", "ruber", "rubicon", "rubicundus"},
+		},
+		{
+			name:     "prefix ending part way along an edge",
+			prefix:   "romu",
+			trie:     getTrie(7, 'r'),
+			expected: []string{"romulus"},
+		},
+		{
+			name:     "prefix which is itself an entry",
+			prefix:   "slow",
+			trie:     getTrie(3, 's'),
+			expected: []string{"slow", "slower", "slowly"},
+		},
+		{
+			name:     "prefix longer than any entry",
+			prefix:   "slowest",
+			trie:     getTrie(3, 's'),
+			expected: []string{},
+		},
+		{
+			name:     "chinese prefix",
+			prefix:   "大",
+			trie:     getStringTrie(2, "大"),
+			expected: []string{"大蒜", "大豆"},
+		},
+	}
+
+	for _, test := range prefixTests {
+		checkValid(t, test.name, &test.trie)
+		entries := test.trie.WithPrefix(test.prefix)
+		if !reflect.DeepEqual(entries, test.expected) {
+			t.Errorf("test '%s': expected %v, but was %v", test.name, test.expected, entries)
+		}
+	}
+}
+
+func TestLongestPrefix(t *testing.T) {
+
+	longestTests := []struct {
+		name     string
+		value    string
+		trie     Trie
+		expected string
+		found    bool
+	}{
+		{
+			name:     "empty string",
+			value:    "",
+			trie:     getTrie(3, 's'),
+			expected: "",
+			found:    false,
+		},
+		{
+			name:     "exact entry",
+			value:    "slower",
+			trie:     getTrie(3, 's'),
+			expected: "slower",
+			found:    true,
+		},
+		{
+			name:     "entry followed by other text",
+			value:    "slowness",
+			trie:     getTrie(3, 's'),
+			expected: "slow",
+			found:    true,
+		},
+		{
+			name:     "no entry is a prefix",
+			value:    "slo",
+			trie:     getTrie(3, 's'),
+			expected: "",
+			found:    false,
+		},
+		{
+			name:     "chinese entry followed by other text",
+			value:    "大豆油",
+			trie:     getStringTrie(2, "大"),
+			expected: "大豆",
+			found:    true,
+		},
+	}
+
+	for _, test := range longestTests {
+		checkValid(t, test.name, &test.trie)
+		longest, found := test.trie.LongestPrefix(test.value)
+		if longest != test.expected || found != test.found {
+			t.Errorf("test '%s': expected ('%s', %t), but was ('%s', %t)", test.name, test.expected, test.found, longest, found)
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+
+	deleteTests := []struct {
+		name          string
+		value         string
+		trie          Trie
+		expected      Trie
+		expectedCount int
+		deleted       bool
+	}{
+		{
+			name:          "delete from empty trie",
+			value:         "romane",
+			trie:          getTrie(0, 'r'),
+			expected:      getTrie(0, 'r'),
+			expectedCount: 0,
+			deleted:       false,
+		},
+		{
+			name:          "delete only element",
+			value:         "romane",
+			trie:          getTrie(1, 'r'),
+			expected:      getTrie(0, 'r'),
+			expectedCount: 0,
+			deleted:       true,
+		},
+		{
+			name:          "delete trimmed element",
+			value:         " romanus\n",
+			trie:          getTrie(2, 'r'),
+			expected:      getTrie(1, 'r'),
+			expectedCount: 1,
+			deleted:       true,
+		},
+		{
+			name:          "delete nonexistent element",
+			value:         "romulus",
+			trie:          getTrie(2, 'r'),
+			expected:      getTrie(2, 'r'),
+			expectedCount: 2,
+			deleted:       false,
+		},
+		{
+			name:          "delete prefix which is not an entry",
+			value:         "roman",
+			trie:          getTrie(2, 'r'),
+			expected:      getTrie(2, 'r'),
+			expectedCount: 2,
+			deleted:       false,
+		},
+		{
+			name:          "delete last inserted element",
+			value:         "rubicundus",
+			trie:          getTrie(7, 'r'),
+			expected:      getTrie(6, 'r'),
+			expectedCount: 6,
+			deleted:       true,
+		},
+		{
+			name:          "delete element which leaves a node to be merged",
+			value:         "ruber",
+			trie:          getTrie(5, 'r'),
+			expected:      getTrie(4, 'r'),
+			expectedCount: 4,
+			deleted:       true,
+		},
+		{
+			name:          "delete entry which is not a leaf",
+			value:         "slow",
+			trie:          getTrie(2, 's'),
+			expected:      Trie{child: []*Node{{value: "s", children: []*Node{{value: "lower", entry: true}}, childCount: 1}}, count: 1},
+			expectedCount: 1,
+			deleted:       true,
+		},
+		{
+			name:          "delete leaf below an entry",
+			value:         "slowly",
+			trie:          getTrie(3, 's'),
+			expected:      getTrie(2, 's'),
+			expectedCount: 2,
+			deleted:       true,
+		},
+		{
+			name:          "delete chinese element",
+			value:         "大豆",
+			trie:          getStringTrie(2, "大"),
+			expected:      getStringTrie(1, "大"),
+			expectedCount: 1,
+			deleted:       true,
+		},
+	}
+
+	for _, test := range deleteTests {
+		deleted := test.trie.Delete(test.value)
+		checkValid(t, test.name, &test.trie)
+		if deleted != test.deleted {
+			t.Errorf("test '%s': expected deleted to be %t", test.name, test.deleted)
+		}
+		if test.trie.Count() != test.expectedCount {
+			t.Errorf("test '%s': expected count to be %d, but was %d", test.name, test.expectedCount, test.trie.Count())
+		}
+		if test.trie.String() != test.expected.String() {
+			t.Errorf("test '%s': expected structure\n%s\nbut was\n%s", test.name, test.expected.String(), test.trie.String())
+		}
+	}
+}
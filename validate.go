@@ -0,0 +1,145 @@
+package trie
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Validate checks the structural invariants of the trie, returning
+// an error describing the first violation found (or nil). These are:
+//
+//   - every root node holds exactly one rune, is not itself an entry
+//     and has at least one child; no two roots hold the same rune
+//   - every other node has a non-empty value which is valid UTF-8
+//     (so edges are always split on rune boundaries)
+//   - every node below the roots which is not an entry has at least
+//     two children (otherwise it should have been merged)
+//   - no two siblings have values which begin with the same rune
+//   - the child count of every node agrees with its children, as
+//     does its child index (if that is up to date)
+//   - only entries hold data or weights
+//   - in a case-insensitive trie, every entry (and nothing else)
+//     holds the original terms which fold to it
+//   - no node is reachable by more than one path
+//   - the entry count of the trie agrees with its entry nodes
+//   - the suffix index (if any) is itself valid, with as many entries
+//   - in an arena-backed trie, every node lies within the arena and
+//     holds the first rune of its edge
+func (t *Trie) Validate() error {
+
+	if t.arena != nil {
+		if err := t.arena.check(); err != nil {
+			return err
+		}
+		// The suffix index is checked as it is, rather than as a copy
+		tree := t.tree()
+		tree.reversed = t.reversed
+		return tree.Validate()
+	}
+
+	v := validator{trie: t, seen: map[*Node]bool{}}
+
+	roots := map[string]bool{}
+	for _, c := range t.child {
+		if c == nil {
+			return fmt.Errorf("trie: nil root node")
+		}
+		if !utf8.ValidString(c.value) || utf8.RuneCountInString(c.value) != 1 {
+			return fmt.Errorf("trie: root node %q does not hold exactly one rune", c.value)
+		}
+		if roots[c.value] {
+			return fmt.Errorf("trie: duplicate root node %q", c.value)
+		}
+		roots[c.value] = true
+		if c.entry {
+			return fmt.Errorf("trie: root node %q is an entry", c.value)
+		}
+		if len(c.children) == 0 {
+			return fmt.Errorf("trie: root node %q has no children", c.value)
+		}
+		if err := v.node(c, c.value, true); err != nil {
+			return err
+		}
+	}
+
+	if err := t.roots.check(t.child); err != nil {
+		return fmt.Errorf("trie: roots: %w", err)
+	}
+
+	if v.entries != t.count {
+		return fmt.Errorf("trie: count is %d but there are %d entries", t.count, v.entries)
+	}
+
+	if t.reversed != nil {
+		if err := t.reversed.Validate(); err != nil {
+			return fmt.Errorf("trie: suffix index: %w", err)
+		}
+		if t.reversed.count != t.count {
+			return fmt.Errorf("trie: count is %d but the suffix index has %d entries", t.count, t.reversed.count)
+		}
+	}
+	return nil
+}
+
+type validator struct {
+	trie    *Trie
+	seen    map[*Node]bool
+	entries int
+}
+
+func (v *validator) node(n *Node, path string, root bool) error {
+
+	if v.seen[n] {
+		return fmt.Errorf("trie: node %q is reachable by more than one path", path)
+	}
+	v.seen[n] = true
+
+	if n.entry {
+		v.entries++
+	} else if n.data != "" || n.weight != 0 {
+		return fmt.Errorf("trie: node %q holds data or a weight but is not an entry", path)
+	}
+	if n.entry && v.trie.fold {
+		if len(n.keys) == 0 {
+			return fmt.Errorf("trie: node %q is an entry but holds no original terms", path)
+		}
+		for _, k := range n.keys {
+			if v.trie.foldKey(k) != path {
+				return fmt.Errorf("trie: node %q holds the term %q which does not fold to it", path, k)
+			}
+		}
+	} else if len(n.keys) != 0 {
+		return fmt.Errorf("trie: node %q holds original terms but is not a case-insensitive entry", path)
+	}
+	if n.childCount != len(n.children) {
+		return fmt.Errorf("trie: node %q has child count %d but %d children", path, n.childCount, len(n.children))
+	}
+	if !root && !n.entry && len(n.children) < 2 {
+		return fmt.Errorf("trie: node %q is not an entry but has %d children", path, len(n.children))
+	}
+
+	first := map[rune]bool{}
+	for _, c := range n.children {
+		if c == nil {
+			return fmt.Errorf("trie: node %q has a nil child", path)
+		}
+		if c.value == "" {
+			return fmt.Errorf("trie: node %q has a child with an empty value", path)
+		}
+		if !utf8.ValidString(c.value) {
+			return fmt.Errorf("trie: node %q has a child %q which is not valid UTF-8", path, c.value)
+		}
+		r, _ := utf8.DecodeRuneInString(c.value)
+		if first[r] {
+			return fmt.Errorf("trie: node %q has more than one child beginning with %q", path, r)
+		}
+		first[r] = true
+		if err := v.node(c, path+c.value, false); err != nil {
+			return err
+		}
+	}
+	if err := n.index.check(n.children); err != nil {
+		return fmt.Errorf("trie: node %q: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package trie
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+
+	shared := &Node{value: "us", entry: true}
+	cyclic := &Node{value: "oman", childCount: 2}
+	cyclic.children = []*Node{{value: "e", entry: true}, cyclic}
+
+	validateTests := []struct {
+		name  string
+		trie  Trie
+		error string
+	}{
+		{
+			name:  "empty trie",
+			trie:  NewTrie(),
+			error: "",
+		},
+		{
+			name:  "valid trie",
+			trie:  getTrie(7, 'r'),
+			error: "",
+		},
+		{
+			name: "root with more than one rune",
+			trie: Trie{child: []*Node{{value: "ro", childCount: 1,
+				children: []*Node{{value: "mane", entry: true}}}}, count: 1},
+			error: "does not hold exactly one rune",
+		},
+		{
+			name: "root with part of a rune",
+			trie: Trie{child: []*Node{{value: "\xe5", childCount: 1,
+				children: []*Node{{value: "\xa4\xa7蒜", entry: true}}}}, count: 1},
+			error: "does not hold exactly one rune",
+		},
+		{
+			name: "duplicate roots",
+			trie: Trie{child: []*Node{
+				{value: "r", childCount: 1, children: []*Node{{value: "omane", entry: true}}},
+				{value: "r", childCount: 1, children: []*Node{{value: "ubens", entry: true}}}}, count: 2},
+			error: "duplicate root",
+		},
+		{
+			name:  "root without children",
+			trie:  Trie{child: []*Node{{value: "r"}}},
+			error: "has no children",
+		},
+		{
+			name: "child with an empty value",
+			trie: Trie{child: []*Node{{value: "r", childCount: 2,
+				children: []*Node{{value: "omane", entry: true}, {value: "", entry: true}}}}, count: 2},
+			error: "empty value",
+		},
+		{
+			name: "child with invalid UTF-8",
+			trie: Trie{child: []*Node{{value: "大", childCount: 1,
+				children: []*Node{{value: "\xe8\x92", entry: true}}}}, count: 1},
+			error: "not valid UTF-8",
+		},
+		{
+			name: "non-entry with one child",
+			trie: Trie{child: []*Node{{value: "r", childCount: 1,
+				children: []*Node{{value: "om", childCount: 1,
+					children: []*Node{{value: "ane", entry: true}}}}}}, count: 1},
+			error: "is not an entry but has 1 children",
+		},
+		{
+			name: "siblings sharing a first rune",
+			trie: Trie{child: []*Node{{value: "r", childCount: 2,
+				children: []*Node{{value: "omane", entry: true}, {value: "omulus", entry: true}}}}, count: 2},
+			error: "more than one child beginning with 'o'",
+		},
+		{
+			name: "child count disagrees with children",
+			trie: Trie{child: []*Node{{value: "r", childCount: 1,
+				children: []*Node{{value: "omane", entry: true}, {value: "ubens", entry: true}}}}, count: 2},
+			error: "has child count 1 but 2 children",
+		},
+		{
+			name: "data on a non-entry",
+			trie: Trie{child: []*Node{{value: "r", childCount: 2, data: "x",
+				children: []*Node{{value: "omane", entry: true}, {value: "ubens", entry: true}}}}, count: 2},
+			error: "holds data or a weight but is not an entry",
+		},
+		{
+			name: "shared node",
+			trie: Trie{child: []*Node{{value: "r", childCount: 2,
+				children: []*Node{{value: "oman", childCount: 1, entry: true, children: []*Node{shared}},
+					{value: "ubens", childCount: 1, entry: true, children: []*Node{shared}}}}}, count: 3},
+			error: "more than one path",
+		},
+		{
+			name:  "cyclic node",
+			trie:  Trie{child: []*Node{{value: "r", childCount: 1, children: []*Node{cyclic}}}, count: 1},
+			error: "more than one path",
+		},
+		{
+			name:  "count disagrees with entries",
+			trie:  Trie{child: []*Node{{value: "r", childCount: 1, children: []*Node{{value: "omane", entry: true}}}}, count: 2},
+			error: "count is 2 but there are 1 entries",
+		},
+	}
+
+	for _, test := range validateTests {
+		err := test.trie.Validate()
+		if test.error == "" {
+			if err != nil {
+				t.Errorf("test '%s': unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.error) {
+			t.Errorf("test '%s': expected error containing '%s', but was %v", test.name, test.error, err)
+		}
+	}
+}